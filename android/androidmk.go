@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/google/blueprint"
@@ -155,6 +156,8 @@ func translateAndroidMkModule(ctx SingletonContext, w io.Writer, mod blueprint.M
 	switch x := mod.(type) {
 	case AndroidMkDataProvider:
 		return translateAndroidModule(ctx, w, mod, x)
+	case AndroidMkEntriesProvider:
+		return translateAndroidMkEntriesModule(ctx, w, mod, x)
 	case bootstrap.GoBinaryTool:
 		return translateGoBinaryModule(ctx, w, mod, x)
 	default:
@@ -173,60 +176,96 @@ func translateGoBinaryModule(ctx SingletonContext, w io.Writer, mod blueprint.Mo
 	return nil
 }
 
-func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Module,
-	provider AndroidMkDataProvider) error {
+// AndroidMkEntriesProvider is implemented by modules that describe themselves to Make as a list
+// of structured AndroidMkEntries, one per Make module.
+type AndroidMkEntriesProvider interface {
+	AndroidMkEntries() []AndroidMkEntries
+	BaseModuleName() string
+}
 
-	name := provider.BaseModuleName()
-	amod := mod.(Module).base()
+// AndroidMkEntries describes a single Make module.  The common LOCAL_* variables are filled in
+// from the Soong module, any further variables are added by the ExtraEntries functions.
+type AndroidMkEntries struct {
+	Class      string
+	SubName    string
+	DistFile   OptionalPath
+	OutputFile OptionalPath
+	Disabled   bool
+	Include    string
+	Required   []string
 
-	if !amod.Enabled() {
-		return nil
-	}
+	// ExtraEntries are called in order after the common entries have been set.
+	ExtraEntries []AndroidMkExtraEntriesFunc
 
-	if amod.commonProperties.SkipInstall {
-		return nil
-	}
+	header bytes.Buffer
+	footer bytes.Buffer
 
-	if !amod.commonProperties.NamespaceExportedToMake {
-		// TODO(jeffrygaston) do we want to validate that there are no modules being
-		// exported to Kati that depend on this module?
-		return nil
-	}
+	// EntryMap holds the values of the make variable assignments, in the order they were added
+	// as recorded in entryOrder.
+	EntryMap   map[string][]string
+	entryOrder []string
+}
 
-	data := provider.AndroidMk()
+type AndroidMkExtraEntriesFunc func(entries *AndroidMkEntries)
 
-	if data.Include == "" {
-		data.Include = "$(BUILD_PREBUILT)"
+// SetString sets the make variable name to value, replacing any previous value.
+func (a *AndroidMkEntries) SetString(name, value string) {
+	if _, ok := a.EntryMap[name]; !ok {
+		a.entryOrder = append(a.entryOrder, name)
 	}
+	a.EntryMap[name] = []string{value}
+}
 
-	data.Required = append(data.Required, amod.commonProperties.Required...)
+// SetBool sets the make variable name to "true" or "false".
+func (a *AndroidMkEntries) SetBool(name string, flag bool) {
+	a.SetString(name, strconv.FormatBool(flag))
+}
 
-	// Make does not understand LinuxBionic
-	if amod.Os() == LinuxBionic {
-		return nil
+// SetBoolIfTrue sets the make variable name to "true" if flag is true.
+func (a *AndroidMkEntries) SetBoolIfTrue(name string, flag bool) {
+	if flag {
+		a.SetString(name, "true")
 	}
+}
 
-	prefix := ""
-	if amod.ArchSpecific() {
-		switch amod.Os().Class {
-		case Host:
-			prefix = "HOST_"
-		case HostCross:
-			prefix = "HOST_CROSS_"
-		case Device:
-			prefix = "TARGET_"
+// AddStrings appends values to the make variable name.  Nothing is written if there are no values.
+func (a *AndroidMkEntries) AddStrings(name string, value ...string) {
+	if len(value) == 0 {
+		return
+	}
+	if _, ok := a.EntryMap[name]; !ok {
+		a.entryOrder = append(a.entryOrder, name)
+	}
+	a.EntryMap[name] = append(a.EntryMap[name], value...)
+}
 
-		}
+// fillInEntries sets the entries common to all Make modules generated from mod, then calls the
+// ExtraEntries functions.
+func (a *AndroidMkEntries) fillInEntries(config Config, bpPath string, mod blueprint.Module) {
+	a.EntryMap = make(map[string][]string)
+	a.entryOrder = nil
+	a.header.Reset()
+	a.footer.Reset()
 
-		if amod.Arch().ArchType != ctx.Config().Targets[amod.Os()][0].Arch.ArchType {
-			prefix = "2ND_" + prefix
-		}
+	amod := mod.(Module).base()
+	name := mod.(interface {
+		BaseModuleName() string
+	}).BaseModuleName()
+
+	if a.Include == "" {
+		a.Include = "$(BUILD_PREBUILT)"
 	}
 
+	a.Required = append(a.Required, amod.commonProperties.Required...)
+
 	if len(amod.commonProperties.Dist.Targets) > 0 {
-		distFile := data.DistFile
+		distFile := a.DistFile
+		if amod.commonProperties.Dist.Tag != nil {
+			// Already validated in GenerateBuildActions.
+			distFile, _ = amod.distTagOutputFile()
+		}
 		if !distFile.Valid() {
-			distFile = data.OutputFile
+			distFile = a.OutputFile
 		}
 		if distFile.Valid() {
 			dest := filepath.Base(distFile.String())
@@ -256,21 +295,19 @@ func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Mod
 			}
 
 			goals := strings.Join(amod.commonProperties.Dist.Targets, " ")
-			fmt.Fprintln(&data.preamble, ".PHONY:", goals)
-			fmt.Fprintf(&data.preamble, "$(call dist-for-goals,%s,%s:%s)\n",
+			fmt.Fprintln(&a.header, ".PHONY:", goals)
+			fmt.Fprintf(&a.header, "$(call dist-for-goals,%s,%s:%s)\n",
 				goals, distFile.String(), dest)
 		}
 	}
 
-	fmt.Fprintln(&data.preamble, "\ninclude $(CLEAR_VARS)")
-	fmt.Fprintln(&data.preamble, "LOCAL_PATH :=", filepath.Dir(ctx.BlueprintFile(mod)))
-	fmt.Fprintln(&data.preamble, "LOCAL_MODULE :=", name+data.SubName)
-	fmt.Fprintln(&data.preamble, "LOCAL_MODULE_CLASS :=", data.Class)
-	fmt.Fprintln(&data.preamble, "LOCAL_PREBUILT_MODULE_FILE :=", data.OutputFile.String())
+	fmt.Fprintln(&a.header, "\ninclude $(CLEAR_VARS)")
 
-	if len(data.Required) > 0 {
-		fmt.Fprintln(&data.preamble, "LOCAL_REQUIRED_MODULES := "+strings.Join(data.Required, " "))
-	}
+	a.SetString("LOCAL_PATH", filepath.Dir(bpPath))
+	a.SetString("LOCAL_MODULE", name+a.SubName)
+	a.SetString("LOCAL_MODULE_CLASS", a.Class)
+	a.SetString("LOCAL_PREBUILT_MODULE_FILE", a.OutputFile.String())
+	a.AddStrings("LOCAL_REQUIRED_MODULES", a.Required...)
 
 	archStr := amod.Arch().ArchType.String()
 	host := false
@@ -278,49 +315,37 @@ func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Mod
 	case Host:
 		// Make cannot identify LOCAL_MODULE_HOST_ARCH:= common.
 		if archStr != "common" {
-			fmt.Fprintln(&data.preamble, "LOCAL_MODULE_HOST_ARCH :=", archStr)
+			a.SetString("LOCAL_MODULE_HOST_ARCH", archStr)
 		}
 		host = true
 	case HostCross:
 		// Make cannot identify LOCAL_MODULE_HOST_CROSS_ARCH:= common.
 		if archStr != "common" {
-			fmt.Fprintln(&data.preamble, "LOCAL_MODULE_HOST_CROSS_ARCH :=", archStr)
+			a.SetString("LOCAL_MODULE_HOST_CROSS_ARCH", archStr)
 		}
 		host = true
 	case Device:
 		// Make cannot identify LOCAL_MODULE_TARGET_ARCH:= common.
 		if archStr != "common" {
-			fmt.Fprintln(&data.preamble, "LOCAL_MODULE_TARGET_ARCH :=", archStr)
+			a.SetString("LOCAL_MODULE_TARGET_ARCH", archStr)
 		}
 
-		if len(amod.commonProperties.Init_rc) > 0 {
-			fmt.Fprintln(&data.preamble, "LOCAL_INIT_RC := ", strings.Join(amod.commonProperties.Init_rc, " "))
-		}
-		if len(amod.commonProperties.Vintf_fragments) > 0 {
-			fmt.Fprintln(&data.preamble, "LOCAL_VINTF_FRAGMENTS := ", strings.Join(amod.commonProperties.Vintf_fragments, " "))
-		}
-		if Bool(amod.commonProperties.Proprietary) {
-			fmt.Fprintln(&data.preamble, "LOCAL_PROPRIETARY_MODULE := true")
-		}
+		a.AddStrings("LOCAL_SOONG_INIT_RC", amod.initRcPaths.Strings()...)
+		a.AddStrings("LOCAL_SOONG_VINTF_FRAGMENTS", amod.vintfFragmentsPaths.Strings()...)
+		a.SetBoolIfTrue("LOCAL_PROPRIETARY_MODULE", Bool(amod.commonProperties.Proprietary))
 		if Bool(amod.commonProperties.Vendor) || Bool(amod.commonProperties.Soc_specific) {
-			fmt.Fprintln(&data.preamble, "LOCAL_VENDOR_MODULE := true")
-		}
-		if Bool(amod.commonProperties.Device_specific) {
-			fmt.Fprintln(&data.preamble, "LOCAL_ODM_MODULE := true")
-		}
-		if Bool(amod.commonProperties.Product_specific) {
-			fmt.Fprintln(&data.preamble, "LOCAL_PRODUCT_MODULE := true")
-		}
-		if Bool(amod.commonProperties.Product_services_specific) {
-			fmt.Fprintln(&data.preamble, "LOCAL_PRODUCT_SERVICES_MODULE := true")
+			a.SetString("LOCAL_VENDOR_MODULE", "true")
 		}
+		a.SetBoolIfTrue("LOCAL_ODM_MODULE", Bool(amod.commonProperties.Device_specific))
+		a.SetBoolIfTrue("LOCAL_PRODUCT_MODULE", Bool(amod.commonProperties.Product_specific))
+		a.SetBoolIfTrue("LOCAL_PRODUCT_SERVICES_MODULE", Bool(amod.commonProperties.Product_services_specific))
 		if amod.commonProperties.Owner != nil {
-			fmt.Fprintln(&data.preamble, "LOCAL_MODULE_OWNER :=", *amod.commonProperties.Owner)
+			a.SetString("LOCAL_MODULE_OWNER", *amod.commonProperties.Owner)
 		}
 	}
 
 	if amod.noticeFile.Valid() {
-		fmt.Fprintln(&data.preamble, "LOCAL_NOTICE_FILE :=", amod.noticeFile.String())
+		a.SetString("LOCAL_NOTICE_FILE", amod.noticeFile.String())
 	}
 
 	if host {
@@ -328,10 +353,103 @@ func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Mod
 		if amod.Os() == Linux || amod.Os() == LinuxBionic {
 			makeOs = "linux"
 		}
-		fmt.Fprintln(&data.preamble, "LOCAL_MODULE_HOST_OS :=", makeOs)
-		fmt.Fprintln(&data.preamble, "LOCAL_IS_HOST_MODULE := true")
+		a.SetString("LOCAL_MODULE_HOST_OS", makeOs)
+		a.SetString("LOCAL_IS_HOST_MODULE", "true")
+	}
+
+	for _, extra := range a.ExtraEntries {
+		extra(a)
 	}
 
+	fmt.Fprintln(&a.footer, "include "+a.Include)
+}
+
+// writeHeaderAndEntries writes the header and the make variable assignments, but not the footer.
+func (a *AndroidMkEntries) writeHeaderAndEntries(w io.Writer) {
+	w.Write(a.header.Bytes())
+	for _, name := range a.entryOrder {
+		fmt.Fprintln(w, name+" := "+strings.Join(a.EntryMap[name], " "))
+	}
+}
+
+func (a *AndroidMkEntries) write(w io.Writer) {
+	if a.Disabled {
+		return
+	}
+
+	if !a.OutputFile.Valid() {
+		return
+	}
+
+	a.writeHeaderAndEntries(w)
+	w.Write(a.footer.Bytes())
+}
+
+// shouldSkipAndroidMkProcessing returns true if the module should not be written to the Make
+// makefile at all.
+func shouldSkipAndroidMkProcessing(amod *ModuleBase) bool {
+	if !amod.Enabled() {
+		return true
+	}
+
+	if amod.commonProperties.SkipInstall {
+		return true
+	}
+
+	if !amod.commonProperties.NamespaceExportedToMake {
+		// TODO(jeffrygaston) do we want to validate that there are no modules being
+		// exported to Kati that depend on this module?
+		return true
+	}
+
+	// Make does not understand LinuxBionic
+	return amod.Os() == LinuxBionic
+}
+
+func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Module,
+	provider AndroidMkDataProvider) error {
+
+	name := provider.BaseModuleName()
+	amod := mod.(Module).base()
+
+	if shouldSkipAndroidMkProcessing(amod) {
+		return nil
+	}
+
+	data := provider.AndroidMk()
+
+	prefix := ""
+	if amod.ArchSpecific() {
+		switch amod.Os().Class {
+		case Host:
+			prefix = "HOST_"
+		case HostCross:
+			prefix = "HOST_CROSS_"
+		case Device:
+			prefix = "TARGET_"
+
+		}
+
+		if amod.Arch().ArchType != ctx.Config().Targets[amod.Os()][0].Arch.ArchType {
+			prefix = "2ND_" + prefix
+		}
+	}
+
+	// The common variables are shared with AndroidMkEntries, write them into the preamble.
+	entries := AndroidMkEntries{
+		Class:      data.Class,
+		SubName:    data.SubName,
+		DistFile:   data.DistFile,
+		OutputFile: data.OutputFile,
+		Disabled:   data.Disabled,
+		Include:    data.Include,
+		Required:   data.Required,
+	}
+	entries.fillInEntries(ctx.Config(), ctx.BlueprintFile(mod), mod)
+	entries.writeHeaderAndEntries(&data.preamble)
+	data.Include = entries.Include
+	data.Required = entries.Required
+
 	blueprintDir := filepath.Dir(ctx.BlueprintFile(mod))
 
 	if data.Custom != nil {
@@ -343,6 +461,21 @@ func translateAndroidModule(ctx SingletonContext, w io.Writer, mod blueprint.Mod
 	return nil
 }
 
+func translateAndroidMkEntriesModule(ctx SingletonContext, w io.Writer, mod blueprint.Module,
+	provider AndroidMkEntriesProvider) error {
+
+	if shouldSkipAndroidMkProcessing(mod.(Module).base()) {
+		return nil
+	}
+
+	for _, entries := range provider.AndroidMkEntries() {
+		entries.fillInEntries(ctx.Config(), ctx.BlueprintFile(mod), mod)
+		entries.write(w)
+	}
+
+	return nil
+}
+
 func WriteAndroidMkData(w io.Writer, data AndroidMkData) {
 	if data.Disabled {
 		return
@@ -0,0 +1,128 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type androidMkEntriesTestModule struct {
+	ModuleBase
+
+	output       Path
+	configOutput Path
+}
+
+func androidMkEntriesTestModuleFactory() Module {
+	module := &androidMkEntriesTestModule{}
+	InitAndroidArchModule(module, DeviceSupported, MultilibFirst)
+	return module
+}
+
+func (m *androidMkEntriesTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	m.output = PathForModuleOut(ctx, "foo.so")
+	m.configOutput = PathForModuleOut(ctx, "foo.config")
+}
+
+func (m *androidMkEntriesTestModule) AndroidMkEntries() []AndroidMkEntries {
+	return []AndroidMkEntries{
+		{
+			Class:      "SHARED_LIBRARIES",
+			OutputFile: OptionalPathForPath(m.output),
+			ExtraEntries: []AndroidMkExtraEntriesFunc{
+				func(entries *AndroidMkEntries) {
+					entries.AddStrings("LOCAL_REQUIRED_MODULES", "foo.config")
+					entries.SetBool("LOCAL_UNINSTALLABLE_MODULE", false)
+				},
+			},
+		},
+		{
+			Class:      "ETC",
+			SubName:    ".config",
+			OutputFile: OptionalPathForPath(m.configOutput),
+			ExtraEntries: []AndroidMkExtraEntriesFunc{
+				func(entries *AndroidMkEntries) {
+					entries.SetBoolIfTrue("LOCAL_IS_TEST_CONFIG", false)
+					entries.SetString("LOCAL_MODULE_STEM", "foo.config")
+				},
+			},
+		},
+	}
+}
+
+func TestAndroidMkEntries(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "soong_androidmk_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	config := TestArchConfig(buildDir, nil)
+	ctx := NewTestArchContext()
+	ctx.RegisterModuleType("custom", ModuleFactoryAdaptor(androidMkEntriesTestModuleFactory))
+	ctx.Register()
+
+	ctx.MockFileSystem(map[string][]byte{
+		"foo/Android.bp": []byte(`
+			custom {
+				name: "foo",
+				required: ["bar"],
+			}
+		`),
+	})
+	_, errs := ctx.ParseFileList(".", []string{"foo/Android.bp"})
+	FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	FailIfErrored(t, errs)
+
+	mod := ctx.ModuleForTests("foo", "android_arm64_armv8-a").Module()
+	entriesList := AndroidMkEntriesForTest(t, config, "foo/Android.bp", mod)
+	if len(entriesList) != 2 {
+		t.Fatalf("expected 2 AndroidMkEntries, got %d", len(entriesList))
+	}
+
+	buf := &bytes.Buffer{}
+	for i := range entriesList {
+		entriesList[i].write(buf)
+	}
+
+	expected := "\n" +
+		"include $(CLEAR_VARS)\n" +
+		"LOCAL_PATH := foo\n" +
+		"LOCAL_MODULE := foo\n" +
+		"LOCAL_MODULE_CLASS := SHARED_LIBRARIES\n" +
+		"LOCAL_PREBUILT_MODULE_FILE := " + buildDir + "/.intermediates/foo/foo/android_arm64_armv8-a/foo.so\n" +
+		"LOCAL_REQUIRED_MODULES := bar foo.config\n" +
+		"LOCAL_MODULE_TARGET_ARCH := arm64\n" +
+		"LOCAL_UNINSTALLABLE_MODULE := false\n" +
+		"include $(BUILD_PREBUILT)\n" +
+		"\n" +
+		"include $(CLEAR_VARS)\n" +
+		"LOCAL_PATH := foo\n" +
+		"LOCAL_MODULE := foo.config\n" +
+		"LOCAL_MODULE_CLASS := ETC\n" +
+		"LOCAL_PREBUILT_MODULE_FILE := " + buildDir + "/.intermediates/foo/foo/android_arm64_armv8-a/foo.config\n" +
+		"LOCAL_REQUIRED_MODULES := bar\n" +
+		"LOCAL_MODULE_TARGET_ARCH := arm64\n" +
+		"LOCAL_MODULE_STEM := foo.config\n" +
+		"include $(BUILD_PREBUILT)\n"
+
+	if g, w := buf.String(), expected; g != w {
+		t.Errorf("incorrect Android.mk output:\nwant:\n%s\ngot:\n%s", w, g)
+	}
+}
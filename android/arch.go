@@ -557,6 +557,11 @@ func newArch(name, multilib string) ArchType {
 	return archType
 }
 
+// ArchTypeList returns all the supported architecture types.
+func ArchTypeList() []ArchType {
+	return append([]ArchType(nil), archTypeList...)
+}
+
 func (a ArchType) String() string {
 	return a.Name
 }
@@ -750,8 +755,8 @@ func archMutator(mctx BottomUpMutatorContext) {
 			continue
 		}
 
-		// only the primary arch in the recovery partition
-		if os == Android && module.InstallInRecovery() {
+		// only the primary arch in the recovery partition and the ramdisk
+		if os == Android && (module.InstallInRecovery() || module.InstallInRamdisk()) {
 			osTargets = []Target{osTargets[0]}
 		}
 
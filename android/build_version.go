@@ -0,0 +1,208 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The build_version singleton generates a C header and a Java source file containing the build
+// fingerprint, security patch level and platform version, so that modules can compile them in
+// instead of reading build.prop or calling getprop at build time.  Only values that are stable
+// across builds of a product are included, see buildNumberPlaceholder.  Modules use the generated
+// files through a build_version_files module: cc modules list it in generated_headers to include
+// <android/build_version.h>, and java modules list ":<name>" in srcs to compile BuildVersion.java.
+
+func init() {
+	RegisterModuleType("build_version_files", BuildVersionFilesFactory)
+	RegisterSingletonType("build_version", BuildVersionSingleton)
+}
+
+// buildNumberPlaceholder stands for the build number in the fingerprint.  The build number changes
+// on every build, so it is kept out of the generated files to avoid recompiling everything that
+// uses them.  cc modules that need it set use_version_lib and call android::build::GetBuildNumber()
+// from libbuildversion, which reads the number injected by symbol_inject.
+const buildNumberPlaceholder = "@BUILD_NUMBER@"
+
+func BuildVersionSingleton() Singleton {
+	return &buildVersionSingleton{}
+}
+
+type buildVersionSingleton struct{}
+
+// BuildVersionHeaderPath returns the path to the generated build version header.  Modules that
+// include it should add the directory to their include path and depend on the file.
+func BuildVersionHeaderPath(ctx PathContext) OutputPath {
+	return PathForOutput(ctx, "build_version", "include", "android", "build_version.h")
+}
+
+// buildVersionIncludeDir returns the include directory of the generated build version header.
+func buildVersionIncludeDir(ctx PathContext) OutputPath {
+	return PathForOutput(ctx, "build_version", "include")
+}
+
+// BuildVersionJavaPath returns the path to the generated BuildVersion.java.
+func BuildVersionJavaPath(ctx PathContext) OutputPath {
+	return PathForOutput(ctx, "build_version", "java", "android", "build", "BuildVersion.java")
+}
+
+// buildVariant returns the TARGET_BUILD_VARIANT equivalent for the current product.
+func buildVariant(config Config) string {
+	if config.Eng() {
+		return "eng"
+	} else if config.Debuggable() {
+		return "userdebug"
+	}
+	return "user"
+}
+
+// buildFingerprint returns the build fingerprint, with the build number left as a placeholder.
+func buildFingerprint(config Config) string {
+	if config.productVariables.BuildFingerprint != nil {
+		return *config.productVariables.BuildFingerprint
+	}
+	return fmt.Sprintf("%s/%s/%s:%s/%s/%s:%s/%s",
+		String(config.productVariables.ProductBrand),
+		String(config.productVariables.ProductName),
+		config.DeviceName(),
+		config.PlatformVersionName(),
+		config.BuildId(),
+		buildNumberPlaceholder,
+		buildVariant(config),
+		String(config.productVariables.BuildVersionTags))
+}
+
+func buildVersionHeaderContent(config Config) string {
+	lines := []string{
+		"// Generated by Soong, do not edit.",
+		"#pragma once",
+		"",
+		fmt.Sprintf("#define ANDROID_BUILD_FINGERPRINT %q", buildFingerprint(config)),
+		fmt.Sprintf("#define ANDROID_BUILD_ID %q", config.BuildId()),
+		fmt.Sprintf("#define ANDROID_PLATFORM_VERSION %q", config.PlatformVersionName()),
+		fmt.Sprintf("#define ANDROID_PLATFORM_SDK_VERSION %d", config.PlatformSdkVersionInt()),
+		fmt.Sprintf("#define ANDROID_PLATFORM_SECURITY_PATCH %q", config.PlatformSecurityPatch()),
+	}
+	return writeFileContent(lines)
+}
+
+func buildVersionJavaContent(config Config) string {
+	lines := []string{
+		"// Generated by Soong, do not edit.",
+		"package android.build;",
+		"",
+		"public final class BuildVersion {",
+		fmt.Sprintf("    public static final String FINGERPRINT = %q;", buildFingerprint(config)),
+		fmt.Sprintf("    public static final String ID = %q;", config.BuildId()),
+		fmt.Sprintf("    public static final String PLATFORM_VERSION = %q;", config.PlatformVersionName()),
+		fmt.Sprintf("    public static final int PLATFORM_SDK_VERSION = %d;", config.PlatformSdkVersionInt()),
+		fmt.Sprintf("    public static final String SECURITY_PATCH = %q;", config.PlatformSecurityPatch()),
+		"",
+		"    private BuildVersion() {}",
+		"}",
+	}
+	return writeFileContent(lines)
+}
+
+// writeFileContent joins lines into the content argument of the WriteFile rule, which passes it
+// through ninja and expands it with echo -e inside single quotes.
+func writeFileContent(lines []string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "'", `'\''`, "$", "$$")
+	for i, line := range lines {
+		lines[i] = escaper.Replace(line)
+	}
+	return strings.Join(lines, "\\n")
+}
+
+func (s *buildVersionSingleton) generate(ctx SingletonContext, out WritablePath, content string) {
+	ctx.Build(pctx, BuildParams{
+		Rule:        WriteFile,
+		Description: "generate " + out.Base(),
+		Output:      out,
+		Args: map[string]string{
+			"content": content,
+		},
+	})
+}
+
+func (s *buildVersionSingleton) GenerateBuildActions(ctx SingletonContext) {
+	s.generate(ctx, BuildVersionHeaderPath(ctx), buildVersionHeaderContent(ctx.Config()))
+	s.generate(ctx, BuildVersionJavaPath(ctx), buildVersionJavaContent(ctx.Config()))
+}
+
+// MakeVars exports the build fingerprint with the build number placeholder left in, Make
+// substitutes it with $(BUILD_NUMBER_FROM_FILE) where the fingerprint is used.
+func (s *buildVersionSingleton) MakeVars(ctx MakeVarsContext) {
+	ctx.Strict("SOONG_BUILD_VERSION_HEADER", BuildVersionHeaderPath(ctx).String())
+	ctx.Strict("SOONG_BUILD_VERSION_JAVA", BuildVersionJavaPath(ctx).String())
+	ctx.Strict("SOONG_BUILD_NUMBER_PLACEHOLDER", buildNumberPlaceholder)
+	ctx.Strict("SOONG_BUILD_FINGERPRINT", buildFingerprint(ctx.Config()))
+	ctx.Strict("SOONG_PLATFORM_VERSION_NAME", ctx.Config().PlatformVersionName())
+	ctx.Strict("SOONG_PLATFORM_SECURITY_PATCH", ctx.Config().PlatformSecurityPatch())
+}
+
+// buildVersionFiles is a module that exposes the files generated by the build_version singleton
+// to other modules.
+type buildVersionFiles struct {
+	ModuleBase
+
+	header     Path
+	includeDir Path
+	java       Path
+}
+
+// build_version_files exposes the generated build version header and Java source to other
+// modules.  cc modules can list it in generated_headers to include <android/build_version.h>,
+// and java modules can list ":<name>" in srcs to compile the android.build.BuildVersion class.
+// The header can also be referenced with ":<name>{build_version.h}".
+func BuildVersionFilesFactory() Module {
+	module := &buildVersionFiles{}
+	InitAndroidModule(module)
+	return module
+}
+
+func (m *buildVersionFiles) GenerateAndroidBuildActions(ctx ModuleContext) {
+	m.header = BuildVersionHeaderPath(ctx)
+	m.includeDir = buildVersionIncludeDir(ctx)
+	m.java = BuildVersionJavaPath(ctx)
+}
+
+func (m *buildVersionFiles) OutputFiles(tag string) (Paths, error) {
+	switch tag {
+	case "", m.java.Base():
+		return Paths{m.java}, nil
+	case m.header.Base():
+		return Paths{m.header}, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+	}
+}
+
+var _ OutputFileProducer = (*buildVersionFiles)(nil)
+
+// GeneratedSourceFiles, GeneratedHeaderDirs and GeneratedDeps allow cc modules to list the module
+// in generated_headers, as they would a genrule.
+func (m *buildVersionFiles) GeneratedSourceFiles() Paths {
+	return nil
+}
+
+func (m *buildVersionFiles) GeneratedHeaderDirs() Paths {
+	return Paths{m.includeDir}
+}
+
+func (m *buildVersionFiles) GeneratedDeps() Paths {
+	return Paths{m.header}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildFingerprint(t *testing.T) {
+	config := TestConfig("out", nil)
+	config.TestProductVariables.ProductBrand = stringPtr("Android")
+	config.TestProductVariables.ProductName = stringPtr("test_product")
+	config.TestProductVariables.Platform_version_name = stringPtr("10")
+	config.TestProductVariables.BuildId = stringPtr("QP1A")
+	config.TestProductVariables.Debuggable = boolPtr(true)
+	config.TestProductVariables.BuildVersionTags = stringPtr("test-keys")
+
+	want := "Android/test_product/test_device:10/QP1A/" + buildNumberPlaceholder + ":userdebug/test-keys"
+	if got := buildFingerprint(config); got != want {
+		t.Errorf("want fingerprint %q, got %q", want, got)
+	}
+
+	config.TestProductVariables.BuildFingerprint = stringPtr("custom/fingerprint")
+	if got := buildFingerprint(config); got != "custom/fingerprint" {
+		t.Errorf("want overridden fingerprint %q, got %q", "custom/fingerprint", got)
+	}
+}
+
+func TestWriteFileContent(t *testing.T) {
+	got := writeFileContent([]string{`#define A "a\\b"`, `#define B "it's $1|2"`})
+	want := `#define A "a\\\\b"` + `\n` + `#define B "it'\''s $$1|2"`
+	if got != want {
+		t.Errorf("want content %q, got %q", want, got)
+	}
+}
+
+func TestBuildVersionSingleton(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "soong_build_version_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	config := TestConfig(buildDir, nil)
+	config.TestProductVariables.Platform_security_patch = stringPtr("2019-09-05")
+
+	ctx := NewTestContext()
+	ctx.MockFileSystem(map[string][]byte{
+		"Android.bp": []byte(`
+			build_version_files {
+				name: "build_version",
+			}
+		`),
+	})
+	ctx.RegisterModuleType("build_version_files", ModuleFactoryAdaptor(BuildVersionFilesFactory))
+	ctx.RegisterSingletonType("build_version", SingletonFactoryAdaptor(BuildVersionSingleton))
+	ctx.Register()
+
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	FailIfErrored(t, errs)
+
+	singleton := ctx.SingletonForTests("build_version")
+
+	header := singleton.Output("build_version/include/android/build_version.h")
+	if content := header.Args["content"]; !strings.Contains(content, `#define ANDROID_PLATFORM_SECURITY_PATCH "2019-09-05"`) {
+		t.Errorf("security patch missing from header content %q", content)
+	}
+	if content := header.Args["content"]; strings.Contains(content, "ANDROID_BUILD_NUMBER") {
+		t.Errorf("unexpected build number in header content %q", content)
+	}
+
+	java := singleton.Output("build_version/java/android/build/BuildVersion.java")
+	if content := java.Args["content"]; !strings.Contains(content, "PLATFORM_SDK_VERSION = 26;") {
+		t.Errorf("sdk version missing from java content %q", content)
+	}
+
+	m := ctx.ModuleForTests("build_version", "").Module().(*buildVersionFiles)
+	for _, test := range []struct {
+		tag  string
+		want string
+	}{
+		{"", "build_version/java/android/build/BuildVersion.java"},
+		{"BuildVersion.java", "build_version/java/android/build/BuildVersion.java"},
+		{"build_version.h", "build_version/include/android/build_version.h"},
+	} {
+		files, err := m.OutputFiles(test.tag)
+		if err != nil {
+			t.Errorf("unexpected error for tag %q: %s", test.tag, err)
+		} else if g, w := files.Strings(), []string{filepath.Join(buildDir, test.want)}; !reflect.DeepEqual(g, w) {
+			t.Errorf("want output files %q for tag %q, got %q", w, test.tag, g)
+		}
+	}
+	if g, w := m.GeneratedHeaderDirs().Strings(), []string{filepath.Join(buildDir, "build_version/include")}; !reflect.DeepEqual(g, w) {
+		t.Errorf("want header dirs %q, got %q", w, g)
+	}
+}
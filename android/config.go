@@ -639,6 +639,12 @@ func (c *config) Debuggable() bool {
 	return Bool(c.productVariables.Debuggable)
 }
 
+// MiniDebugInfoEnabled returns true if stripped device binaries should embed a compressed
+// symbol table (mini-debug-info) unless the module asks to strip all symbols.
+func (c *config) MiniDebugInfoEnabled() bool {
+	return proptools.BoolDefault(c.productVariables.Strip_mini_debug_info, true)
+}
+
 func (c *config) Eng() bool {
 	return Bool(c.productVariables.Eng)
 }
@@ -734,6 +740,18 @@ func (c *config) TidyChecks() string {
 	return *c.productVariables.TidyChecks
 }
 
+// ClangVersion returns the clang prebuilt version selected by the product, for example
+// "clang-r353983c", or "" to use the default version.
+func (c *config) ClangVersion() string {
+	return String(c.productVariables.ClangVersion)
+}
+
+// ClangShortVersion returns the release version of the clang prebuilt selected by the product,
+// for example "9.0.3", or "" to use the default version.
+func (c *config) ClangShortVersion() string {
+	return String(c.productVariables.ClangShortVersion)
+}
+
 func (c *config) LibartImgHostBaseAddress() string {
 	return "0x60000000"
 }
@@ -802,10 +820,35 @@ func (c *config) DexpreoptGlobalConfig() string {
 	return String(c.productVariables.DexpreoptGlobalConfig)
 }
 
+// DexpreoptBootImageProfiles returns the checked-in profiles the product selected to compile the boot
+// image with.  If empty the profiles listed in the global dexpreopt config are used.
+func (c *config) DexpreoptBootImageProfiles() []string {
+	return c.productVariables.DexpreoptBootImageProfiles
+}
+
+// DexpreoptPreloadedClasses returns the preloaded-classes file the product selected, or an empty
+// string to use the one listed in the global dexpreopt config.
+func (c *config) DexpreoptPreloadedClasses() string {
+	return String(c.productVariables.DexpreoptPreloadedClasses)
+}
+
 func (c *config) FrameworksBaseDirExists(ctx PathContext) bool {
 	return ExistentPathForSource(ctx, "frameworks", "base").Valid()
 }
 
+const (
+	// RuleValidationWarn prints warnings for problems found by the validation of RuleBuilder and genrule actions.
+	RuleValidationWarn = "warn"
+	// RuleValidationStrict fails the build for problems found by the validation of RuleBuilder and genrule actions.
+	RuleValidationStrict = "strict"
+)
+
+// RuleValidation returns RuleValidationWarn or RuleValidationStrict if RuleBuilder and genrule actions should validate
+// their declared outputs and depfiles after running, or "" if they should not.
+func (c *config) RuleValidation() string {
+	return String(c.productVariables.RuleValidation)
+}
+
 func (c *deviceConfig) Arches() []Arch {
 	var arches []Arch
 	for _, target := range c.config.Targets[Android] {
@@ -882,6 +925,24 @@ func (c *deviceConfig) DeviceKernelHeaderDirs() []string {
 	return c.config.productVariables.DeviceKernelHeaders
 }
 
+// NativeSymbols returns the kind of symbol files to generate for installed native binaries and
+// libraries, "breakpad" or "split_debug", or "" if none should be generated.
+func (c *deviceConfig) NativeSymbols() string {
+	return String(c.config.productVariables.NativeSymbols)
+}
+
+// BasebandVersion returns the required version of the prebuilt radio image, or "" if any
+// version is accepted.
+func (c *deviceConfig) BasebandVersion() string {
+	return String(c.config.productVariables.BasebandVersion)
+}
+
+// BootloaderVersion returns the required version of the prebuilt bootloader image, or "" if any
+// version is accepted.
+func (c *deviceConfig) BootloaderVersion() string {
+	return String(c.config.productVariables.BootloaderVersion)
+}
+
 func (c *deviceConfig) NativeCoverageEnabled() bool {
 	return Bool(c.config.productVariables.NativeCoverage)
 }
@@ -901,10 +962,56 @@ func (c *deviceConfig) CoverageEnabledForPath(path string) bool {
 	return coverage
 }
 
+// JavaCoverageEnabledForPath returns whether java modules in the given directory should be
+// instrumented with jacoco when building with EMMA_INSTRUMENT=true.  All directories are
+// instrumented unless JavaCoveragePaths restricts the set, and JavaCoverageExcludePaths
+// takes precedence over JavaCoveragePaths.
+func (c *deviceConfig) JavaCoverageEnabledForPath(path string) bool {
+	coverage := false
+	if len(c.config.productVariables.JavaCoveragePaths) == 0 ||
+		InList("*", c.config.productVariables.JavaCoveragePaths) ||
+		PrefixInList(path, c.config.productVariables.JavaCoveragePaths) {
+		coverage = true
+	}
+	if coverage && c.config.productVariables.JavaCoverageExcludePaths != nil {
+		if PrefixInList(path, c.config.productVariables.JavaCoverageExcludePaths) {
+			coverage = false
+		}
+	}
+	return coverage
+}
+
 func (c *deviceConfig) PgoAdditionalProfileDirs() []string {
 	return c.config.productVariables.PgoAdditionalProfileDirs
 }
 
+// PgoInstrumentBenchmarks returns the list of PGO benchmarks whose modules should be built with
+// profile instrumentation, as selected by the product or the ANDROID_PGO_INSTRUMENT environment
+// variable.
+func (c *deviceConfig) PgoInstrumentBenchmarks() []string {
+	benchmarks := append([]string(nil), c.config.productVariables.PgoInstrument...)
+	if env := c.config.Getenv("ANDROID_PGO_INSTRUMENT"); env != "" {
+		benchmarks = append(benchmarks, strings.Split(env, ",")...)
+	}
+	return benchmarks
+}
+
+// PgoProfileUseEnabled returns true if checked-in PGO profiles should be applied when compiling.
+func (c *deviceConfig) PgoProfileUseEnabled() bool {
+	return !Bool(c.config.productVariables.PgoNoProfileUse) &&
+		!c.config.IsEnvTrue("ANDROID_PGO_NO_PROFILE_USE")
+}
+
+func (c *deviceConfig) AfdoAdditionalProfileDirs() []string {
+	return c.config.productVariables.AfdoAdditionalProfileDirs
+}
+
+// AfdoProfileUseEnabled returns true if checked-in AFDO profiles should be applied when compiling.
+func (c *deviceConfig) AfdoProfileUseEnabled() bool {
+	return !Bool(c.config.productVariables.AfdoNoProfileUse) &&
+		!c.config.IsEnvTrue("ANDROID_AFDO_NO_PROFILE_USE")
+}
+
 func (c *deviceConfig) VendorSepolicyDirs() []string {
 	return c.config.productVariables.BoardVendorSepolicyDirs
 }
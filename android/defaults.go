@@ -15,6 +15,9 @@
 package android
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 )
@@ -61,15 +64,26 @@ func InitDefaultableModule(module DefaultableModule) {
 	module.AddProperties(module.defaults())
 }
 
+type defaultsVisibilityProperties struct {
+	// Controls which modules may list this defaults module in their defaults property.  Each
+	// rule is one of "//visibility:public", "//visibility:private" (modules in the same
+	// directory), "//<dir>:__pkg__" (modules in <dir>) or "//<dir>:__subpackages__" (modules in
+	// <dir> or any directory below it).  Defaults to public.
+	Defaults_visibility []string
+}
+
 type DefaultsModuleBase struct {
 	DefaultableModuleBase
 	defaultProperties []interface{}
+
+	defaultsVisibilityProperties defaultsVisibilityProperties
 }
 
 type Defaults interface {
 	Defaultable
 	isDefaults() bool
 	properties() []interface{}
+	visibility() *defaultsVisibilityProperties
 }
 
 func (d *DefaultsModuleBase) isDefaults() bool {
@@ -80,6 +94,10 @@ func (d *DefaultsModuleBase) properties() []interface{} {
 	return d.defaultableProperties
 }
 
+func (d *DefaultsModuleBase) visibility() *defaultsVisibilityProperties {
+	return &d.defaultsVisibilityProperties
+}
+
 func InitDefaultsModule(module DefaultableModule) {
 	module.AddProperties(
 		&hostAndDeviceProperties{},
@@ -89,13 +107,25 @@ func InitDefaultsModule(module DefaultableModule) {
 	InitArchModule(module)
 	InitDefaultableModule(module)
 
-	module.AddProperties(&module.base().nameProperties)
+	// Added after InitDefaultableModule so that defaults_visibility is not inherited by defaults
+	// modules that use this one.
+	module.AddProperties(&module.base().nameProperties,
+		module.(Defaults).visibility())
 
 	module.base().module = module
 }
 
 var _ Defaults = (*DefaultsModuleBase)(nil)
 
+// defaultsModule is the defaults module type used by the module types in this package.
+type defaultsModule struct {
+	ModuleBase
+	DefaultsModuleBase
+}
+
+func (*defaultsModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+}
+
 func (defaultable *DefaultableModuleBase) applyDefaults(ctx TopDownMutatorContext,
 	defaultsList []Defaults) {
 
@@ -128,7 +158,86 @@ func defaultsDepsMutator(ctx BottomUpMutatorContext) {
 	}
 }
 
+// parseDefaultsVisibilityRule splits a defaults_visibility rule into the directory it applies to
+// and its kind, one of "public", "private", "__pkg__" or "__subpackages__".
+func parseDefaultsVisibilityRule(rule string) (dir, kind string, err error) {
+	switch rule {
+	case "//visibility:public":
+		return "", "public", nil
+	case "//visibility:private":
+		return "", "private", nil
+	}
+
+	if !strings.HasPrefix(rule, "//") {
+		return "", "", fmt.Errorf("invalid rule %q, must start with //", rule)
+	}
+	colon := strings.LastIndex(rule, ":")
+	if colon == -1 {
+		return "", "", fmt.Errorf("invalid rule %q, must end with :__pkg__ or :__subpackages__", rule)
+	}
+	dir, kind = rule[2:colon], rule[colon+1:]
+	if kind != "__pkg__" && kind != "__subpackages__" {
+		return "", "", fmt.Errorf("invalid rule %q, must end with :__pkg__ or :__subpackages__", rule)
+	}
+	return dir, kind, nil
+}
+
+// defaultsVisibleFrom returns true if a module in dir may use a defaults module in defaultsDir
+// with the given defaults_visibility rules.  Invalid rules are ignored, they are reported on the
+// defaults module itself.
+func defaultsVisibleFrom(rules []string, defaultsDir, dir string) bool {
+	if len(rules) == 0 {
+		return true
+	}
+
+	for _, rule := range rules {
+		ruleDir, kind, err := parseDefaultsVisibilityRule(rule)
+		if err != nil {
+			continue
+		}
+		switch kind {
+		case "public":
+			return true
+		case "private":
+			if dir == defaultsDir {
+				return true
+			}
+		case "__pkg__":
+			if dir == ruleDir {
+				return true
+			}
+		case "__subpackages__":
+			if ruleDir == "" || dir == ruleDir || strings.HasPrefix(dir, ruleDir+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkDefaultsVisibility(ctx TopDownMutatorContext) {
+	if defaults, ok := ctx.Module().(Defaults); ok {
+		for _, rule := range defaults.visibility().Defaults_visibility {
+			if _, _, err := parseDefaultsVisibilityRule(rule); err != nil {
+				ctx.PropertyErrorf("defaults_visibility", "%s", err.Error())
+			}
+		}
+	}
+
+	ctx.VisitDirectDepsWithTag(DefaultsDepTag, func(module Module) {
+		if defaults, ok := module.(Defaults); ok {
+			rules := defaults.visibility().Defaults_visibility
+			if !defaultsVisibleFrom(rules, ctx.OtherModuleDir(module), ctx.ModuleDir()) {
+				ctx.PropertyErrorf("defaults", "defaults module %q is not visible from %q, defaults_visibility: %q",
+					ctx.OtherModuleName(module), ctx.ModuleDir(), rules)
+			}
+		}
+	})
+}
+
 func defaultsMutator(ctx TopDownMutatorContext) {
+	checkDefaultsVisibility(ctx)
+
 	if defaultable, ok := ctx.Module().(Defaultable); ok && len(defaultable.defaults().Defaults) > 0 {
 		var defaultsList []Defaults
 		seen := make(map[Defaults]bool)
@@ -0,0 +1,179 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testDefaults(t *testing.T, fs map[string][]byte) (*TestContext, []error) {
+	buildDir, err := ioutil.TempDir("", "soong_defaults_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	config := TestArchConfig(buildDir, nil)
+	ctx := NewTestArchContext()
+	ctx.RegisterModuleType("prebuilt_etc", ModuleFactoryAdaptor(PrebuiltEtcFactory))
+	ctx.RegisterModuleType("prebuilt_etc_defaults", ModuleFactoryAdaptor(PrebuiltEtcDefaultsFactory))
+	ctx.PreArchMutators(RegisterDefaultsPreArchMutators)
+	ctx.PreDepsMutators(func(ctx RegisterMutatorsContext) {
+		ctx.BottomUp("prebuilt_etc", prebuiltEtcMutator).Parallel()
+	})
+	ctx.Register()
+
+	var bpFiles []string
+	for file := range fs {
+		bpFiles = append(bpFiles, file)
+	}
+	fs["foo.conf"] = nil
+	fs["top/foo.conf"] = nil
+	fs["top/nested/foo.conf"] = nil
+	fs["other/foo.conf"] = nil
+	ctx.MockFileSystem(fs)
+
+	_, errs := ctx.ParseFileList(".", bpFiles)
+	if len(errs) > 0 {
+		return ctx, errs
+	}
+	_, errs = ctx.PrepareBuildActions(config)
+	return ctx, errs
+}
+
+func TestDefaultsInheritDefaults(t *testing.T) {
+	ctx, errs := testDefaults(t, map[string][]byte{
+		"Android.bp": []byte(`
+			prebuilt_etc_defaults {
+				name: "base_defaults",
+				sub_dir: "base",
+				filename_from_src: true,
+			}
+
+			prebuilt_etc_defaults {
+				name: "derived_defaults",
+				defaults: ["base_defaults"],
+				sub_dir: "derived",
+			}
+
+			prebuilt_etc {
+				name: "foo",
+				defaults: ["derived_defaults"],
+				src: "foo.conf",
+			}
+		`),
+	})
+	FailIfErrored(t, errs)
+
+	p := ctx.ModuleForTests("foo", "android_arm64_armv8-a_core").Module().(*PrebuiltEtc)
+	if g, w := p.SubDir(), "derived"; g != w {
+		t.Errorf("want sub_dir %q, got %q", w, g)
+	}
+	if g, w := p.OutputFile().Base(), "foo.conf"; g != w {
+		t.Errorf("want output %q, got %q", w, g)
+	}
+}
+
+func TestDefaultsVisibility(t *testing.T) {
+	defaultsBp := `
+		prebuilt_etc_defaults {
+			name: "public_defaults",
+		}
+
+		prebuilt_etc_defaults {
+			name: "private_defaults",
+			defaults_visibility: ["//visibility:private"],
+		}
+
+		prebuilt_etc_defaults {
+			name: "subpackages_defaults",
+			defaults_visibility: ["//top:__subpackages__"],
+		}
+
+		prebuilt_etc_defaults {
+			name: "pkg_defaults",
+			defaults_visibility: ["//top/nested:__pkg__"],
+		}
+	`
+
+	tests := []struct {
+		name string
+		dir  string
+		uses string
+		err  string
+	}{
+		{name: "public", dir: "other", uses: "public_defaults"},
+		{name: "private same dir", dir: "top", uses: "private_defaults"},
+		{
+			name: "private other dir",
+			dir:  "top/nested",
+			uses: "private_defaults",
+			err:  `defaults module "private_defaults" is not visible from "top/nested"`,
+		},
+		{name: "subpackages", dir: "top/nested", uses: "subpackages_defaults"},
+		{
+			name: "subpackages outside",
+			dir:  "other",
+			uses: "subpackages_defaults",
+			err:  `defaults module "subpackages_defaults" is not visible from "other"`,
+		},
+		{name: "pkg", dir: "top/nested", uses: "pkg_defaults"},
+		{
+			name: "pkg other dir",
+			dir:  "top",
+			uses: "pkg_defaults",
+			err:  `defaults module "pkg_defaults" is not visible from "top"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := map[string][]byte{
+				"top/Android.bp": []byte(defaultsBp),
+			}
+			if test.dir != "top" {
+				fs[test.dir+"/Android.bp"] = []byte("")
+			}
+			fs[test.dir+"/Android.bp"] = append(fs[test.dir+"/Android.bp"], []byte(`
+				prebuilt_etc {
+					name: "foo",
+					defaults: ["`+test.uses+`"],
+					src: "foo.conf",
+				}
+			`)...)
+
+			_, errs := testDefaults(t, fs)
+			if test.err == "" {
+				FailIfErrored(t, errs)
+			} else {
+				FailIfNoMatchingErrors(t, test.err, errs)
+			}
+		})
+	}
+}
+
+func TestDefaultsVisibilityInvalidRule(t *testing.T) {
+	_, errs := testDefaults(t, map[string][]byte{
+		"Android.bp": []byte(`
+			prebuilt_etc_defaults {
+				name: "bad_defaults",
+				defaults_visibility: ["top:__pkg__"],
+			}
+		`),
+	})
+	FailIfNoMatchingErrors(t, `invalid rule "top:__pkg__", must start with //`, errs)
+}
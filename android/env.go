@@ -23,10 +23,11 @@ import (
 
 // This file supports dependencies on environment variables.  During build manifest generation,
 // any dependency on an environment variable is added to a list.  During the singleton phase
-// a JSON file is written containing the current value of all used environment variables.
-// The next time the top-level build script is run, it uses the soong_env executable to
-// compare the contents of the environment variables, rewriting the file if necessary to cause
-// a manifest regeneration.
+// a JSON file, soong.environment.used, is written containing the current value of all used
+// environment variables.  The next time soong_ui is run, it compares the contents of the file
+// against its environment, and removes the file to cause a manifest regeneration only if one
+// of the used environment variables has changed.  Changes to any other environment variables
+// do not cause a regeneration.
 
 var originalEnv map[string]string
 
@@ -50,7 +51,7 @@ type envSingleton struct{}
 func (c *envSingleton) GenerateBuildActions(ctx SingletonContext) {
 	envDeps := ctx.Config().EnvDeps()
 
-	envFile := PathForOutput(ctx, ".soong.environment")
+	envFile := PathForOutput(ctx, env.UsedEnvFile)
 	if ctx.Failed() {
 		return
 	}
@@ -33,7 +33,8 @@ type fileGroupProperties struct {
 	// The base path to the files.  May be used by other modules to determine which portion
 	// of the path to use.  For example, when a filegroup is used as data in a cc_test rule,
 	// the base path is stripped off the path and the remaining path is used as the
-	// installation directory.
+	// installation directory.  The base path is also stripped from java resources, and is
+	// added to the protoc include paths of modules that use .proto files from the filegroup.
 	Path *string
 
 	// Create a make variable with the specified name that contains the list of files in the
@@ -45,6 +46,9 @@ type fileGroup struct {
 	ModuleBase
 	properties fileGroupProperties
 	srcs       Paths
+
+	// protoIncludeDir is the directory exported to users of the filegroup's .proto files.
+	protoIncludeDir OptionalPath
 }
 
 var _ SourceFileProducer = (*fileGroup)(nil)
@@ -64,6 +68,13 @@ func (fg *fileGroup) GenerateAndroidBuildActions(ctx ModuleContext) {
 
 	if fg.properties.Path != nil {
 		fg.srcs = PathsWithModuleSrcSubDir(ctx, fg.srcs, String(fg.properties.Path))
+
+		for _, src := range fg.srcs {
+			if src.Ext() == ".proto" {
+				fg.protoIncludeDir = OptionalPathForPath(pathForModuleSrc(ctx, String(fg.properties.Path)))
+				break
+			}
+		}
 	}
 }
 
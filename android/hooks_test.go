@@ -0,0 +1,107 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type hooksTestModuleProperties struct {
+	Flags []string
+}
+
+type hooksTestModule struct {
+	ModuleBase
+	properties hooksTestModuleProperties
+}
+
+func hooksTestModuleFactory() Module {
+	module := &hooksTestModule{}
+	module.AddProperties(&module.properties)
+	InitAndroidModule(module)
+	AddLoadHook(module, func(ctx LoadHookContext) {
+		ctx.AppendProperties(&hooksTestModuleProperties{
+			Flags: []string{"from_module_type"},
+		})
+	})
+	return module
+}
+
+func (m *hooksTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+}
+
+func TestRegisterLoadHook(t *testing.T) {
+	savedLoadHooks := moduleTypeLoadHooks
+	defer func() {
+		moduleTypeLoadHooks = savedLoadHooks
+	}()
+
+	RegisterLoadHook("test", func(ctx LoadHookContext) {
+		ctx.AppendProperties(&hooksTestModuleProperties{
+			Flags: []string{"from_plugin"},
+		})
+	})
+	RegisterLoadHook("test", func(ctx LoadHookContext) {
+		ctx.PrependProperties(&hooksTestModuleProperties{
+			Flags: []string{"prepended_" + ctx.ModuleName()},
+		})
+	})
+
+	buildDir, err := ioutil.TempDir("", "soong_hooks_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	config := TestConfig(buildDir, nil)
+	ctx := NewTestContext()
+	ctx.RegisterModuleType("test", ModuleFactoryAdaptor(withModuleTypeLoadHooks("test", hooksTestModuleFactory)))
+	ctx.RegisterModuleType("other", ModuleFactoryAdaptor(withModuleTypeLoadHooks("other", hooksTestModuleFactory)))
+	ctx.PreArchMutators(func(ctx RegisterMutatorsContext) {
+		ctx.TopDown("load_hooks", LoadHookMutator).Parallel()
+	})
+	ctx.Register()
+
+	ctx.MockFileSystem(map[string][]byte{
+		"Android.bp": []byte(`
+			test {
+				name: "foo",
+				flags: ["from_bp"],
+			}
+
+			other {
+				name: "bar",
+				flags: ["from_bp"],
+			}
+		`),
+	})
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	FailIfErrored(t, errs)
+
+	foo := ctx.ModuleForTests("foo", "").Module().(*hooksTestModule)
+	if g, w := foo.properties.Flags, []string{"prepended_foo", "from_bp", "from_module_type", "from_plugin"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("want foo flags %q, got %q", w, g)
+	}
+
+	bar := ctx.ModuleForTests("bar", "").Module().(*hooksTestModule)
+	if g, w := bar.properties.Flags, []string{"from_bp", "from_module_type"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("want bar flags %q, got %q", w, g)
+	}
+}
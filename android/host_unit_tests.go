@@ -0,0 +1,94 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// Host unit tests are host test modules that set test_options.unit_test.  They are run as build
+// actions by run_host_test, which writes their results in JUnit XML format to
+// out/soong/test_results/<module>/<variant>/<module>.xml, like every other Soong output.  The
+// directory is exported to Make as SOONG_HOST_UNIT_TEST_RESULTS_DIR.  The host-unit-tests phony
+// target runs all of them, so presubmit can run unit tests without TradeFed.
+
+func init() {
+	RegisterSingletonType("host_unit_tests", HostUnitTestsSingleton)
+}
+
+// HostUnitTestModule is implemented by test modules that can be run as host unit tests.
+type HostUnitTestModule interface {
+	Module
+
+	// HostUnitTestResults returns the results file written by running the test, or an invalid
+	// OptionalPath if the module is not run as a host unit test.
+	HostUnitTestResults() OptionalPath
+}
+
+// CanRunHostUnitTest returns true if the module being built by ctx is a variant that can be run
+// on the machine running the build.
+func CanRunHostUnitTest(ctx ModuleContext) bool {
+	return ctx.Os() == BuildOs
+}
+
+// HostUnitTestCommand adds a run_host_test command to rule that writes the results of the test
+// to the results file of the module being built by ctx, and returns the command and the results
+// file.  The caller adds the run_host_test flags and the command that runs the test.
+func HostUnitTestCommand(ctx ModuleContext, rule *RuleBuilder) (*RuleBuilderCommand, WritablePath) {
+	results := hostUnitTestResultsDir(ctx).Join(ctx, ctx.ModuleName(), ctx.ModuleSubDir(),
+		ctx.ModuleName()+".xml")
+	cmd := rule.Command().
+		Tool(ctx.Config().HostToolPath(ctx, "run_host_test")).
+		FlagWithOutput("-o ", results)
+	return cmd, results
+}
+
+// hostUnitTestResultsDir returns the directory containing the results of all host unit tests.
+func hostUnitTestResultsDir(ctx PathContext) OutputPath {
+	return PathForOutput(ctx, "test_results")
+}
+
+func HostUnitTestsSingleton() Singleton {
+	return &hostUnitTestsSingleton{}
+}
+
+type hostUnitTestsSingleton struct {
+	results Paths
+}
+
+func (s *hostUnitTestsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	s.results = nil
+
+	ctx.VisitAllModules(func(m Module) {
+		if t, ok := m.(HostUnitTestModule); ok && m.Enabled() {
+			if results := t.HostUnitTestResults(); results.Valid() {
+				s.results = append(s.results, results.Path())
+			}
+		}
+	})
+
+	ctx.Build(pctx, BuildParams{
+		Rule:   blueprint.Phony,
+		Output: PathForPhony(ctx, "host-unit-tests"),
+		Inputs: s.results,
+	})
+}
+
+func (s *hostUnitTestsSingleton) MakeVars(ctx MakeVarsContext) {
+	ctx.Strict("SOONG_HOST_UNIT_TEST_RESULTS_DIR", hostUnitTestResultsDir(ctx).String())
+	ctx.Strict("SOONG_HOST_UNIT_TEST_RESULTS", strings.Join(s.results.Strings(), " "))
+}
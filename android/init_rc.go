@@ -0,0 +1,72 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"github.com/google/blueprint"
+)
+
+// The init_rc and vintf_fragments properties of device modules are installed by Soong into
+// <partition>/etc/init and <partition>/etc/vintf/manifest, and exported to Make as
+// LOCAL_SOONG_INIT_RC and LOCAL_SOONG_VINTF_FRAGMENTS.  VINTF manifest fragments are validated
+// against the HAL manifest schema, only the validated copies are installed.
+
+func init() {
+	pctx.HostBinToolVariable("xmllintCmd", "xmllint")
+	pctx.SourcePathVariable("vintfManifestSchema", "system/libvintf/xsd/halManifest/hal_manifest.xsd")
+}
+
+var validateVintfFragment = pctx.AndroidStaticRule("validateVintfFragment",
+	blueprint.RuleParams{
+		Command:     `$xmllintCmd --noout --schema $vintfManifestSchema $in && cp -f $in $out`,
+		CommandDeps: []string{"$xmllintCmd", "$vintfManifestSchema"},
+		Description: "validate vintf fragment $in",
+	})
+
+// generateInitRcAndVintfFragments sets up the init_rc and vintf_fragments files of a device
+// module.  They are installed once per module, by its primary variant, and only if the module
+// itself installed a file.
+func (a *ModuleBase) generateInitRcAndVintfFragments(ctx *androidModuleContext) {
+	if !ctx.Device() {
+		return
+	}
+
+	a.initRcPaths = PathsForModuleSrc(ctx, a.commonProperties.Init_rc)
+
+	a.vintfFragmentsPaths = nil
+	for _, src := range PathsForModuleSrc(ctx, a.commonProperties.Vintf_fragments) {
+		validated := PathForModuleOut(ctx, "vintf_fragments", src.Base())
+		ctx.Build(pctx, BuildParams{
+			Rule:   validateVintfFragment,
+			Output: validated,
+			Input:  src,
+		})
+		a.vintfFragmentsPaths = append(a.vintfFragmentsPaths, validated)
+	}
+
+	if !a.commonProperties.CompilePrimary || len(ctx.installFiles) == 0 {
+		return
+	}
+
+	initRcDir := PathForModuleInstall(ctx, "etc", "init")
+	for _, src := range a.initRcPaths {
+		ctx.InstallFile(initRcDir, src.Base(), src)
+	}
+
+	vintfDir := PathForModuleInstall(ctx, "etc", "vintf", "manifest")
+	for _, src := range a.vintfFragmentsPaths {
+		ctx.InstallFile(vintfDir, src.Base(), src)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -29,12 +30,95 @@ import (
 
 func init() {
 	RegisterMakeVarsProvider(pctx, androidMakeVarsProvider)
+	RegisterMakeVarsProvider(pctx, installedFilesMakeVarsProvider)
 }
 
 func androidMakeVarsProvider(ctx MakeVarsContext) {
 	ctx.Strict("MIN_SUPPORTED_SDK_VERSION", strconv.Itoa(ctx.Config().MinSupportedSdkVersion()))
 }
 
+// installedFilesPartitions returns the partitions whose Soong-installed files are exported to
+// Make as SOONG_<name>_INSTALLED_FILES, keyed by name, with their directories relative to
+// $(PRODUCT_OUT).
+func installedFilesPartitions(config DeviceConfig) map[string]string {
+	return map[string]string{
+		"SYSTEM":   "system",
+		"VENDOR":   config.VendorPath(),
+		"RECOVERY": "recovery",
+		"RAMDISK":  "ramdisk",
+	}
+}
+
+// installedFilesByPartition sorts the installed files into the partitions returned by
+// installedFilesPartitions.  Files are assigned to the partition with the longest matching
+// directory, so that files in system/vendor are not listed as system files when the vendor
+// partition is not split out.  Files outside of the partitions, for example host tools or files
+// installed to data, are dropped.
+func installedFilesByPartition(ctx PathContext, files Paths) map[string][]string {
+	productOut := PathForOutput(ctx, "target", "product", ctx.Config().DeviceName()).String()
+	partitions := installedFilesPartitions(DeviceConfig{ctx.Config().deviceConfig})
+
+	ret := make(map[string][]string)
+	for _, file := range files {
+		rel, ok := MaybeRel(ctx, productOut, file.String())
+		if !ok {
+			continue
+		}
+		match, matchDir := "", ""
+		for name, dir := range partitions {
+			if (rel == dir || strings.HasPrefix(rel, dir+"/")) && len(dir) > len(matchDir) {
+				match, matchDir = name, dir
+			}
+		}
+		if match != "" {
+			ret[match] = append(ret[match], file.String())
+		}
+	}
+
+	for name := range ret {
+		ret[name] = FirstUniqueStrings(ret[name])
+		sort.Strings(ret[name])
+	}
+
+	return ret
+}
+
+// makeEscaper escapes the characters in a path that have a special meaning to Make.  Whitespace
+// cannot be escaped in a Make list and is rejected by installedFilesMakeVarsProvider.
+var makeEscaper = strings.NewReplacer(
+	"$", "$$",
+	"#", "\\#",
+)
+
+func installedFilesMakeVarsProvider(ctx MakeVarsContext) {
+	var files Paths
+	ctx.VisitAllModules(func(m Module) {
+		if m.Enabled() {
+			files = append(files, m.base().filesToInstall()...)
+		}
+	})
+
+	byPartition := installedFilesByPartition(ctx, files)
+
+	var names []string
+	for name := range installedFilesPartitions(ctx.DeviceConfig()) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var escaped []string
+		for _, file := range byPartition[name] {
+			if strings.ContainsAny(file, " \t\n") {
+				ctx.Errorf("installed file %q contains whitespace and cannot be exported to Make", file)
+				continue
+			}
+			escaped = append(escaped, makeEscaper.Replace(file))
+		}
+		ctx.StrictRaw("SOONG_"+name+"_INSTALLED_FILES", strings.Join(escaped, " "))
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // Interface for other packages to use to declare make variables
 type MakeVarsContext interface {
@@ -56,6 +140,13 @@ type MakeVarsContext interface {
 	VisitAllModules(visit func(Module))
 	VisitAllModulesIf(pred func(Module) bool, visit func(Module))
 
+	// GlobWithDeps returns a list of files that match the specified pattern but do not match any
+	// of the patterns in excludes.  Patterns may contain "**" to match files in any subdirectory.
+	// It also adds efficient dependencies to rerun the primary builder whenever a file matching
+	// the pattern as added or removed, without rerunning if a file that does not match the
+	// pattern is added to a searched directory.
+	GlobWithDeps(pattern string, excludes []string) ([]string, error)
+
 	// Verify the make variable matches the Soong version, fail the build
 	// if it does not. If the make variable is empty, just set it.
 	Strict(name, ninjaStr string)
@@ -167,19 +258,48 @@ func (s *makeVarsSingleton) GenerateBuildActions(ctx SingletonContext) {
 		return
 	}
 
-	outBytes := s.writeVars(vars)
+	if err := writeFileIfChanged(outFile, s.writeVars(vars)); err != nil {
+		ctx.Errorf(err.Error())
+	}
+
+	// In audit mode, also write the names of the exported variables so that soong_ui can report
+	// the ones that are not referenced by the makefiles read by Kati.
+	if ctx.Config().IsEnvTrue("SOONG_MAKEVARS_AUDIT") {
+		exportsFile := strings.TrimSuffix(outFile, ".mk") + ".exports"
+		if err := writeFileIfChanged(exportsFile, s.writeExports(vars)); err != nil {
+			ctx.Errorf(err.Error())
+		}
+	}
+}
 
-	if _, err := os.Stat(outFile); err == nil {
-		if data, err := ioutil.ReadFile(outFile); err == nil {
-			if bytes.Equal(data, outBytes) {
-				return
+// writeFileIfChanged writes data to file unless it already contains data, so that the timestamp
+// of the file only changes when its contents change.
+func writeFileIfChanged(file string, data []byte) error {
+	if _, err := os.Stat(file); err == nil {
+		if old, err := ioutil.ReadFile(file); err == nil {
+			if bytes.Equal(old, data) {
+				return nil
 			}
 		}
 	}
 
-	if err := ioutil.WriteFile(outFile, outBytes, 0666); err != nil {
-		ctx.Errorf(err.Error())
+	return ioutil.WriteFile(file, data, 0666)
+}
+
+// writeExports returns the sorted names of the exported variables, one per line.
+func (s *makeVarsSingleton) writeExports(vars []makeVarsVariable) []byte {
+	var names []string
+	for _, v := range vars {
+		names = append(names, "SOONG_"+v.name)
 	}
+	names = FirstUniqueStrings(names)
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		fmt.Fprintln(buf, name)
+	}
+	return buf.Bytes()
 }
 
 func (s *makeVarsSingleton) writeVars(vars []makeVarsVariable) []byte {
@@ -0,0 +1,114 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstalledFilesByPartition(t *testing.T) {
+	testCases := []struct {
+		name       string
+		vendorPath string
+		files      []string
+		expected   map[string][]string
+	}{
+		{
+			name: "partitions",
+			files: []string{
+				"target/product/test_device/system/bin/foo",
+				"target/product/test_device/vendor/lib/libbar.so",
+				"target/product/test_device/system/bin/foo",
+				"target/product/test_device/recovery/root/system/bin/baz",
+				"target/product/test_device/ramdisk/bin/qux",
+				"target/product/test_device/system/app/Abc/Abc.apk",
+			},
+			expected: map[string][]string{
+				"SYSTEM": {
+					"out/target/product/test_device/system/app/Abc/Abc.apk",
+					"out/target/product/test_device/system/bin/foo",
+				},
+				"VENDOR": {
+					"out/target/product/test_device/vendor/lib/libbar.so",
+				},
+				"RECOVERY": {
+					"out/target/product/test_device/recovery/root/system/bin/baz",
+				},
+				"RAMDISK": {
+					"out/target/product/test_device/ramdisk/bin/qux",
+				},
+			},
+		},
+		{
+			name:       "vendor in system",
+			vendorPath: "system/vendor",
+			files: []string{
+				"target/product/test_device/system/bin/foo",
+				"target/product/test_device/system/vendor/lib/libbar.so",
+			},
+			expected: map[string][]string{
+				"SYSTEM": {
+					"out/target/product/test_device/system/bin/foo",
+				},
+				"VENDOR": {
+					"out/target/product/test_device/system/vendor/lib/libbar.so",
+				},
+			},
+		},
+		{
+			name: "ignored",
+			files: []string{
+				"host/linux-x86/bin/foo",
+				"target/product/test_device/data/nativetest/foo/foo",
+				"target/product/test_device/systemext/bin/foo",
+			},
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestConfig("out", nil)
+			if test.vendorPath != "" {
+				config.TestProductVariables.VendorPath = stringPtr(test.vendorPath)
+			}
+			ctx := PathContextForTesting(config, nil)
+
+			var files Paths
+			for _, file := range test.files {
+				files = append(files, PathForOutput(ctx, file))
+			}
+
+			got := installedFilesByPartition(ctx, files)
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestMakeVarsExports(t *testing.T) {
+	vars := []makeVarsVariable{
+		{name: "FOO", value: "foo", strict: true},
+		{name: "BAR", value: "bar"},
+		{name: "FOO", value: "foo", strict: true},
+	}
+
+	s := &makeVarsSingleton{}
+	if g, w := string(s.writeExports(vars)), "SOONG_BAR\nSOONG_FOO\n"; g != w {
+		t.Errorf("expected exports %q, got %q", w, g)
+	}
+}
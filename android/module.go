@@ -131,6 +131,7 @@ type ModuleContext interface {
 	InstallInData() bool
 	InstallInSanitizerDir() bool
 	InstallInRecovery() bool
+	InstallInRamdisk() bool
 
 	RequiredModuleNames() []string
 
@@ -187,6 +188,7 @@ type Module interface {
 	InstallInData() bool
 	InstallInSanitizerDir() bool
 	InstallInRecovery() bool
+	InstallInRamdisk() bool
 	SkipInstall()
 	ExportedToMake() bool
 	NoticeFile() OptionalPath
@@ -260,6 +262,9 @@ type commonProperties struct {
 	// Whether this module is installed to recovery partition
 	Recovery *bool
 
+	// Whether this module is installed to ramdisk
+	Ramdisk *bool
+
 	// init.rc files to be installed if this module is installed
 	Init_rc []string `android:"path"`
 
@@ -288,6 +293,10 @@ type commonProperties struct {
 
 		// A suffix to add to the artifact file name (before any extension).
 		Suffix *string `android:"arch_variant"`
+
+		// A string tag to select the OutputFiles associated with the tag. Defaults to the
+		// module's default output files.
+		Tag *string `android:"arch_variant"`
 	} `android:"arch_variant"`
 
 	// Set by TargetMutator
@@ -302,6 +311,10 @@ type commonProperties struct {
 	SkipInstall bool `blueprint:"mutated"`
 
 	NamespaceExportedToMake bool `blueprint:"mutated"`
+
+	// Path of the namespace the module is in, used to find the module referenced by a fully
+	// qualified "//namespace:name" reference among the dependencies of another module.
+	NamespacePath string `blueprint:"mutated"`
 }
 
 type hostAndDeviceProperties struct {
@@ -469,6 +482,9 @@ type ModuleBase struct {
 	checkbuildFiles    Paths
 	noticeFile         OptionalPath
 
+	initRcPaths         Paths
+	vintfFragmentsPaths Paths
+
 	// Used by buildTargetSingleton to create checkbuild and per-directory build targets
 	// Only set on the final variant of each module
 	installTarget    WritablePath
@@ -664,6 +680,10 @@ func (p *ModuleBase) InstallInRecovery() bool {
 	return Bool(p.commonProperties.Recovery)
 }
 
+func (p *ModuleBase) InstallInRamdisk() bool {
+	return Bool(p.commonProperties.Ramdisk)
+}
+
 func (a *ModuleBase) Owner() string {
 	return String(a.commonProperties.Owner)
 }
@@ -672,6 +692,24 @@ func (a *ModuleBase) NoticeFile() OptionalPath {
 	return a.noticeFile
 }
 
+// distTagOutputFile returns the single output file selected by the dist.tag property.
+func (a *ModuleBase) distTagOutputFile() (OptionalPath, error) {
+	tag := String(a.commonProperties.Dist.Tag)
+	producer, ok := a.module.(OutputFileProducer)
+	if !ok {
+		return OptionalPath{}, fmt.Errorf("module does not produce tagged output files")
+	}
+	files, err := producer.OutputFiles(tag)
+	if err != nil {
+		return OptionalPath{}, err
+	}
+	if len(files) != 1 {
+		return OptionalPath{}, fmt.Errorf("tag %q must select exactly one output file, found %d",
+			tag, len(files))
+	}
+	return OptionalPathForPath(files[0]), nil
+}
+
 func (a *ModuleBase) generateModuleTarget(ctx ModuleContext) {
 	allInstalledFiles := Paths{}
 	allCheckbuildFiles := Paths{}
@@ -862,6 +900,18 @@ func (a *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 			return
 		}
 
+		if a.commonProperties.Dist.Tag != nil {
+			if _, err := a.distTagOutputFile(); err != nil {
+				ctx.PropertyErrorf("dist.tag", "%s", err.Error())
+				return
+			}
+		}
+
+		a.generateInitRcAndVintfFragments(ctx)
+		if ctx.Failed() {
+			return
+		}
+
 		a.installFiles = append(a.installFiles, ctx.installFiles...)
 		a.checkbuildFiles = append(a.checkbuildFiles, ctx.checkbuildFiles...)
 	}
@@ -1260,6 +1310,10 @@ func (a *androidModuleContext) InstallInRecovery() bool {
 	return a.module.InstallInRecovery()
 }
 
+func (a *androidModuleContext) InstallInRamdisk() bool {
+	return a.module.InstallInRamdisk()
+}
+
 func (a *androidModuleContext) skipInstall(fullInstallPath OutputPath) bool {
 	if a.module.base().commonProperties.SkipInstall {
 		return true
@@ -1398,48 +1452,78 @@ func isAndroidModule(m blueprint.Module) bool {
 	return ok
 }
 
-func findStringInSlice(str string, slice []string) int {
-	for i, s := range slice {
-		if s == str {
-			return i
-		}
-	}
-	return -1
+// SrcIsModule decodes module references in the format ":name", ":name{.tag}" or "//namespace:name" into the module
+// name, or returns an empty string if the input was not a module reference.
+func SrcIsModule(s string) string {
+	module, _ := SrcIsModuleWithTag(s)
+	return module
 }
 
-func SrcIsModule(s string) string {
+// SrcIsModuleWithTag decodes module references in the format ":name{.tag}" into the module name
+// and the tag, or ":name" into the module name and an empty tag.  References to a module in another
+// namespace in the format "//namespace:name" or "//namespace:name{.tag}" return the fully qualified
+// module name.  It returns empty strings if the input was not a module reference.
+func SrcIsModuleWithTag(s string) (module, tag string) {
 	if len(s) > 1 && s[0] == ':' {
-		return s[1:]
+		module = s[1:]
+	} else if strings.HasPrefix(s, "//") && strings.Contains(s, ":") {
+		module = s
+	} else {
+		return "", ""
+	}
+	if tagStart := strings.IndexByte(module, '{'); tagStart > 0 && module[len(module)-1] == '}' {
+		return module[:tagStart], module[tagStart+1 : len(module)-1]
 	}
-	return ""
+	return module, ""
 }
 
-type sourceDependencyTag struct {
+// unqualifiedModuleName returns the name of a module referenced as "//namespace:name", or the input
+// if it is not a fully qualified module name.
+func unqualifiedModuleName(name string) string {
+	if strings.HasPrefix(name, "//") {
+		return name[strings.LastIndexByte(name, ':')+1:]
+	}
+	return name
+}
+
+type sourceOrOutputDependencyTag struct {
 	blueprint.BaseDependencyTag
+	tag string
+}
+
+// sourceOrOutputDepTag returns the dependency tag used for references to a module using the
+// ":name{tag}" syntax.
+func sourceOrOutputDepTag(tag string) blueprint.DependencyTag {
+	return sourceOrOutputDependencyTag{tag: tag}
 }
 
-var SourceDepTag sourceDependencyTag
+// SourceDepTag is the dependency tag used for references to a module using the ":name" syntax.
+var SourceDepTag = sourceOrOutputDepTag("")
+
+// IsSourceDepTag returns true if depTag is the dependency tag of a ":name" or ":name{tag}"
+// module reference.
+func IsSourceDepTag(depTag blueprint.DependencyTag) bool {
+	_, ok := depTag.(sourceOrOutputDependencyTag)
+	return ok
+}
 
 // Adds necessary dependencies to satisfy filegroup or generated sources modules listed in srcFiles
 // using ":module" syntax, if any.
 //
 // Deprecated: tag the property with `android:"path"` instead.
 func ExtractSourcesDeps(ctx BottomUpMutatorContext, srcFiles []string) {
-	var deps []string
 	set := make(map[string]bool)
 
 	for _, s := range srcFiles {
-		if m := SrcIsModule(s); m != "" {
-			if _, found := set[m]; found {
-				ctx.ModuleErrorf("found source dependency duplicate: %q!", m)
+		if m, t := SrcIsModuleWithTag(s); m != "" {
+			if _, found := set[s]; found {
+				ctx.ModuleErrorf("found source dependency duplicate: %q!", s)
 			} else {
-				set[m] = true
-				deps = append(deps, m)
+				set[s] = true
+				ctx.AddDependency(ctx.Module(), sourceOrOutputDepTag(t), m)
 			}
 		}
 	}
-
-	ctx.AddDependency(ctx.Module(), SourceDepTag, deps...)
 }
 
 // Adds necessary dependencies to satisfy filegroup or generated sources modules specified in s
@@ -1448,8 +1532,8 @@ func ExtractSourcesDeps(ctx BottomUpMutatorContext, srcFiles []string) {
 // Deprecated: tag the property with `android:"path"` instead.
 func ExtractSourceDeps(ctx BottomUpMutatorContext, s *string) {
 	if s != nil {
-		if m := SrcIsModule(*s); m != "" {
-			ctx.AddDependency(ctx.Module(), SourceDepTag, m)
+		if m, t := SrcIsModuleWithTag(*s); m != "" {
+			ctx.AddDependency(ctx.Module(), sourceOrOutputDepTag(t), m)
 		}
 	}
 }
@@ -1458,6 +1542,13 @@ type SourceFileProducer interface {
 	Srcs() Paths
 }
 
+// OutputFileProducer is implemented by modules that produce output files that can be referenced
+// by other modules using the ":name{.tag}" syntax, or selected with the dist.tag property.  The
+// empty tag selects the default output files of the module.
+type OutputFileProducer interface {
+	OutputFiles(tag string) (Paths, error)
+}
+
 type HostToolProvider interface {
 	HostToolPath() OptionalPath
 }
@@ -0,0 +1,44 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import "testing"
+
+func TestSrcIsModuleWithTag(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantModule string
+		wantTag    string
+	}{
+		{in: "foo", wantModule: "", wantTag: ""},
+		{in: ":", wantModule: "", wantTag: ""},
+		{in: ":foo", wantModule: "foo", wantTag: ""},
+		{in: ":foo{.bar}", wantModule: "foo", wantTag: ".bar"},
+		{in: ":foo{}", wantModule: "foo", wantTag: ""},
+		{in: ":foo{.bar", wantModule: "foo{.bar", wantTag: ""},
+		{in: ":{.bar}", wantModule: "{.bar}", wantTag: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			gotModule, gotTag := SrcIsModuleWithTag(test.in)
+			if gotModule != test.wantModule {
+				t.Errorf("want module %q, got %q", test.wantModule, gotModule)
+			}
+			if gotTag != test.wantTag {
+				t.Errorf("want tag %q, got %q", test.wantTag, gotTag)
+			}
+		})
+	}
+}
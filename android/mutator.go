@@ -97,6 +97,10 @@ var postDeps = []RegisterMutatorFunc{
 	registerNeverallowMutator,
 }
 
+// PreArchMutators, PreDepsMutators and PostDepsMutators register mutators that run before the
+// arch mutator, before the deps mutator and after the deps mutator respectively.  They are the
+// supported way for Go packages outside of build/soong to add build logic, and must be called
+// from an init function.
 func PreArchMutators(f RegisterMutatorFunc) {
 	preArch = append(preArch, f)
 }
@@ -120,6 +124,7 @@ type TopDownMutatorContext interface {
 	Module() Module
 
 	OtherModuleName(m blueprint.Module) string
+	OtherModuleDir(m blueprint.Module) string
 	OtherModuleErrorf(m blueprint.Module, fmt string, args ...interface{})
 	OtherModuleDependencyTag(m blueprint.Module) blueprint.DependencyTag
 
@@ -48,6 +48,7 @@ type ModuleInstallPathContext interface {
 	InstallInData() bool
 	InstallInSanitizerDir() bool
 	InstallInRecovery() bool
+	InstallInRamdisk() bool
 }
 
 var _ ModuleInstallPathContext = ModuleContext(nil)
@@ -217,8 +218,9 @@ func ExistentPathsForSources(ctx PathContext, paths []string) Paths {
 	return ret
 }
 
-// PathsForModuleSrc returns Paths rooted from the module's local source directory.  It expands globs and references
-// to SourceFileProducer modules using the ":name" syntax.  Properties passed as the paths argument must have been
+// PathsForModuleSrc returns Paths rooted from the module's local source directory.  It expands globs, references
+// to SourceFileProducer modules using the ":name" syntax, and references to OutputFileProducer modules using the
+// ":name{.tag}" syntax.  Properties passed as the paths argument must have been
 // annotated with struct tag `android:"path"` so that dependencies on SourceFileProducer modules will have already
 // been handled by the path_properties mutator.  If ctx.Config().AllowMissingDependencies() is true, then any missing
 // SourceFileProducer dependencies will cause the module to be marked as having missing dependencies.
@@ -262,16 +264,14 @@ func PathsAndMissingDepsForModuleSrcExcludes(ctx ModuleContext, paths, excludes
 	var missingExcludeDeps []string
 
 	for _, e := range excludes {
-		if m := SrcIsModule(e); m != "" {
-			module := ctx.GetDirectDepWithTag(m, SourceDepTag)
-			if module == nil {
-				missingExcludeDeps = append(missingExcludeDeps, m)
-				continue
-			}
-			if srcProducer, ok := module.(SourceFileProducer); ok {
-				expandedExcludes = append(expandedExcludes, srcProducer.Srcs().Strings()...)
+		if m, t := SrcIsModuleWithTag(e); m != "" {
+			modulePaths, err := getPathsFromModuleDep(ctx, e, m, t)
+			if depErr, ok := err.(missingDependencyError); ok {
+				missingExcludeDeps = append(missingExcludeDeps, depErr.missingDeps...)
+			} else if err != nil {
+				ctx.ModuleErrorf("%s", err.Error())
 			} else {
-				ctx.ModuleErrorf("srcs dependency %q is not a source file producing module", m)
+				expandedExcludes = append(expandedExcludes, modulePaths.Strings()...)
 			}
 		} else {
 			expandedExcludes = append(expandedExcludes, filepath.Join(prefix, e))
@@ -306,26 +306,93 @@ func (e missingDependencyError) Error() string {
 	return "missing dependencies: " + strings.Join(e.missingDeps, ", ")
 }
 
-func expandOneSrcPath(ctx ModuleContext, s string, expandedExcludes []string) (Paths, error) {
-	if m := SrcIsModule(s); m != "" {
-		module := ctx.GetDirectDepWithTag(m, SourceDepTag)
-		if module == nil {
-			return nil, missingDependencyError{[]string{m}}
+// getPathsFromModuleDep returns the paths provided by the module referenced by s using the
+// ":name", ":name{.tag}" or "//namespace:name" syntax.  Untagged references use the sources of a SourceFileProducer if
+// the module is one, and the default output files of an OutputFileProducer otherwise.
+func getPathsFromModuleDep(ctx ModuleContext, s, moduleName, tag string) (Paths, error) {
+	module := getDirectDepForModuleReference(ctx, moduleName, tag)
+	if module == nil {
+		return nil, missingDependencyError{[]string{moduleName}}
+	}
+	if srcProducer, ok := module.(SourceFileProducer); ok && tag == "" {
+		return srcProducer.Srcs(), nil
+	}
+	if outProducer, ok := module.(OutputFileProducer); ok {
+		outputFiles, err := outProducer.OutputFiles(tag)
+		if err != nil {
+			return nil, fmt.Errorf("path dependency %q: %s", s, err.Error())
 		}
-		if srcProducer, ok := module.(SourceFileProducer); ok {
-			moduleSrcs := srcProducer.Srcs()
-			for _, e := range expandedExcludes {
-				for j := 0; j < len(moduleSrcs); j++ {
-					if moduleSrcs[j].String() == e {
-						moduleSrcs = append(moduleSrcs[:j], moduleSrcs[j+1:]...)
-						j--
-					}
+		return outputFiles, nil
+	}
+	if tag != "" {
+		return nil, fmt.Errorf("path dependency %q is not an output file producing module", s)
+	}
+	return nil, fmt.Errorf("path dependency %q is not a source file producing module", moduleName)
+}
+
+// getDirectDepForModuleReference returns the direct dependency added for a ":name" or
+// "//namespace:name" module reference, or nil if there is none.  A module can reference modules
+// with the same name in different namespaces, so the dependency is matched by its namespace too:
+// the referenced namespace for a fully qualified reference, or the first of the namespaces
+// visible to the module that contains a module with the name otherwise.
+func getDirectDepForModuleReference(ctx ModuleContext, moduleName, tag string) Module {
+	name := unqualifiedModuleName(moduleName)
+	namespacePath := ""
+	if name != moduleName {
+		namespacePath = strings.TrimPrefix(moduleName[:len(moduleName)-len(name)-1], "//")
+	}
+
+	// rank returns the position of the namespace of a dependency in the search order for the
+	// reference, or -1 if the reference can't refer to a module in that namespace.
+	rank := func(dep Module) int {
+		depNamespacePath := dep.base().commonProperties.NamespacePath
+		if namespacePath != "" {
+			if depNamespacePath == namespacePath {
+				return 0
+			}
+			return -1
+		}
+		if namespace, ok := ctx.Namespace().(*Namespace); ok && namespace.visibleNamespaces != nil {
+			for i, visible := range namespace.visibleNamespaces {
+				if visible.Path == depNamespacePath {
+					return i
 				}
 			}
-			return moduleSrcs, nil
-		} else {
-			return nil, fmt.Errorf("path dependency %q is not a source file producing module", m)
+			return -1
+		}
+		return 0
+	}
+
+	var module Module
+	moduleRank := -1
+	ctx.VisitDirectDepsWithTag(sourceOrOutputDepTag(tag), func(dep Module) {
+		if ctx.OtherModuleName(dep) != name {
+			return
 		}
+		if r := rank(dep); r >= 0 && (module == nil || r < moduleRank) {
+			module, moduleRank = dep, r
+		}
+	})
+	return module
+}
+
+func expandOneSrcPath(ctx ModuleContext, s string, expandedExcludes []string) (Paths, error) {
+	if m, t := SrcIsModuleWithTag(s); m != "" {
+		modulePaths, err := getPathsFromModuleDep(ctx, s, m, t)
+		if err != nil {
+			return nil, err
+		}
+		var moduleSrcs Paths
+		for _, src := range modulePaths {
+			excluded, err := matchesExcludes(src.String(), expandedExcludes)
+			if err != nil {
+				return nil, err
+			}
+			if !excluded {
+				moduleSrcs = append(moduleSrcs, src)
+			}
+		}
+		return moduleSrcs, nil
 	} else if pathtools.IsGlob(s) {
 		paths := ctx.GlobFiles(pathForModuleSrc(ctx, s).String(), expandedExcludes)
 		return PathsWithModuleSrcSubDir(ctx, paths, ""), nil
@@ -337,14 +404,35 @@ func expandOneSrcPath(ctx ModuleContext, s string, expandedExcludes []string) (P
 			reportPathErrorf(ctx, "module source path %q does not exist", p)
 		}
 
-		j := findStringInSlice(p.String(), expandedExcludes)
-		if j >= 0 {
+		if excluded, err := matchesExcludes(p.String(), expandedExcludes); err != nil {
+			return nil, err
+		} else if excluded {
 			return nil, nil
 		}
 		return Paths{p}, nil
 	}
 }
 
+// matchesExcludes returns true if path is equal to or matches any of the glob patterns in
+// excludes.  Patterns may contain "**" to match any number of directories, the same as the
+// patterns that are passed to GlobWithDeps.
+func matchesExcludes(path string, excludes []string) (bool, error) {
+	for _, e := range excludes {
+		if pathtools.IsGlob(e) {
+			match, err := pathtools.Match(e, path)
+			if err != nil {
+				return false, fmt.Errorf("invalid exclude pattern %q: %s", e, err.Error())
+			}
+			if match {
+				return true, nil
+			}
+		} else if path == e {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // pathsForModuleSrcFromFullPath returns Paths rooted from the module's local
 // source directory, but strip the local source directory from the beginning of
 // each string. If incDirs is false, strip paths with a trailing '/' from the list.
@@ -1170,6 +1258,8 @@ func modulePartition(ctx ModuleInstallPathContext) string {
 	} else if ctx.InstallInRecovery() {
 		// the layout of recovery partion is the same as that of system partition
 		partition = "recovery/root/system"
+	} else if ctx.InstallInRamdisk() {
+		partition = "ramdisk"
 	} else if ctx.SocSpecific() {
 		partition = ctx.DeviceConfig().VendorPath()
 	} else if ctx.DeviceSpecific() {
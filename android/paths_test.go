@@ -205,6 +205,7 @@ type moduleInstallPathContextImpl struct {
 	inData         bool
 	inSanitizerDir bool
 	inRecovery     bool
+	inRamdisk      bool
 }
 
 func (moduleInstallPathContextImpl) Fs() pathtools.FileSystem {
@@ -229,6 +230,10 @@ func (m moduleInstallPathContextImpl) InstallInRecovery() bool {
 	return m.inRecovery
 }
 
+func (m moduleInstallPathContextImpl) InstallInRamdisk() bool {
+	return m.inRamdisk
+}
+
 func TestPathForModuleInstall(t *testing.T) {
 	testConfig := TestConfig("", nil)
 
@@ -262,6 +267,17 @@ func TestPathForModuleInstall(t *testing.T) {
 			in:  []string{"bin", "my_test"},
 			out: "target/product/test_device/system/bin/my_test",
 		},
+		{
+			name: "ramdisk binary",
+			ctx: &moduleInstallPathContextImpl{
+				androidBaseContextImpl: androidBaseContextImpl{
+					target: deviceTarget,
+				},
+				inRamdisk: true,
+			},
+			in:  []string{"bin", "my_test"},
+			out: "target/product/test_device/ramdisk/bin/my_test",
+		},
 		{
 			name: "vendor binary",
 			ctx: &moduleInstallPathContextImpl{
@@ -760,6 +776,40 @@ func (p *pathForModuleSrcTestModule) GenerateAndroidBuildActions(ctx ModuleConte
 	}
 }
 
+type pathForModuleSrcOutputFileProviderModule struct {
+	ModuleBase
+	props struct {
+		Outs   []string
+		Tagged []string
+	}
+
+	outs   Paths
+	tagged Paths
+}
+
+func pathForModuleSrcOutputFileProviderModuleFactory() Module {
+	module := &pathForModuleSrcOutputFileProviderModule{}
+	module.AddProperties(&module.props)
+	InitAndroidModule(module)
+	return module
+}
+
+func (p *pathForModuleSrcOutputFileProviderModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	p.outs = PathsForModuleSrc(ctx, p.props.Outs)
+	p.tagged = PathsForModuleSrc(ctx, p.props.Tagged)
+}
+
+func (p *pathForModuleSrcOutputFileProviderModule) OutputFiles(tag string) (Paths, error) {
+	switch tag {
+	case "":
+		return p.outs, nil
+	case ".tagged":
+		return p.tagged, nil
+	default:
+		return nil, fmt.Errorf("unsupported tag %q", tag)
+	}
+}
+
 type pathForModuleSrcTestCase struct {
 	name string
 	bp   string
@@ -777,18 +827,48 @@ func testPathForModuleSrc(t *testing.T, buildDir string, tests []pathForModuleSr
 
 			ctx.RegisterModuleType("test", ModuleFactoryAdaptor(pathForModuleSrcTestModuleFactory))
 			ctx.RegisterModuleType("filegroup", ModuleFactoryAdaptor(FileGroupFactory))
+			ctx.RegisterModuleType("output_file_provider", ModuleFactoryAdaptor(pathForModuleSrcOutputFileProviderModuleFactory))
+			ctx.RegisterModuleType("soong_namespace", ModuleFactoryAdaptor(NamespaceFactory))
+			ctx.PreArchMutators(RegisterNamespaceMutator)
 
 			fgBp := `
 				filegroup {
 					name: "a",
 					srcs: ["src/a"],
 				}
+
+				filegroup {
+					name: "c",
+					srcs: ["src/a"],
+					path: "src",
+				}
+
+				output_file_provider {
+					name: "b",
+					outs: ["src/b"],
+					tagged: ["src/c"],
+				}
+			`
+
+			nsBp := `
+				soong_namespace {
+				}
+
+				filegroup {
+					name: "a",
+					srcs: ["src/e/e"],
+					path: "src",
+				}
 			`
 
 			mockFS := map[string][]byte{
 				"fg/Android.bp":     []byte(fgBp),
+				"ns/Android.bp":     []byte(nsBp),
+				"ns/src/e/e":        nil,
 				"foo/Android.bp":    []byte(test.bp),
 				"fg/src/a":          nil,
+				"fg/src/b":          nil,
+				"fg/src/c":          nil,
 				"foo/src/b":         nil,
 				"foo/src/c":         nil,
 				"foo/src/d":         nil,
@@ -799,7 +879,7 @@ func testPathForModuleSrc(t *testing.T, buildDir string, tests []pathForModuleSr
 			ctx.MockFileSystem(mockFS)
 
 			ctx.Register()
-			_, errs := ctx.ParseFileList(".", []string{"fg/Android.bp", "foo/Android.bp"})
+			_, errs := ctx.ParseFileList(".", []string{"fg/Android.bp", "ns/Android.bp", "foo/Android.bp"})
 			FailIfErrored(t, errs)
 			_, errs = ctx.PrepareBuildActions(config)
 			FailIfErrored(t, errs)
@@ -860,6 +940,28 @@ func TestPathsForModuleSrc(t *testing.T) {
 			srcs: []string{"foo/src/b", "foo/src/c", "foo/src/d", "foo/src/e/e"},
 			rels: []string{"src/b", "src/c", "src/d", "src/e/e"},
 		},
+		{
+			name: "recursive glob excludes",
+			bp: `
+			test {
+				name: "foo",
+				srcs: ["src/**/*"],
+				exclude_srcs: ["src/e/**/*"],
+			}`,
+			srcs: []string{"foo/src/b", "foo/src/c", "foo/src/d"},
+			rels: []string{"src/b", "src/c", "src/d"},
+		},
+		{
+			name: "recursive glob excludes paths",
+			bp: `
+			test {
+				name: "foo",
+				srcs: ["src/b", "src/e/e"],
+				exclude_srcs: ["**/e"],
+			}`,
+			srcs: []string{"foo/src/b"},
+			rels: []string{"src/b"},
+		},
 		{
 			name: "filegroup",
 			bp: `
@@ -870,6 +972,77 @@ func TestPathsForModuleSrc(t *testing.T) {
 			srcs: []string{"fg/src/a"},
 			rels: []string{"src/a"},
 		},
+		{
+			name: "filegroup recursive glob excludes",
+			bp: `
+			test {
+				name: "foo",
+				srcs: [":a"],
+				exclude_srcs: ["**/*"],
+			}`,
+			srcs: []string{"fg/src/a"},
+			rels: []string{"src/a"},
+		},
+		{
+			name: "filegroup path",
+			bp: `
+			test {
+				name: "foo",
+				srcs: [":c"],
+			}`,
+			srcs: []string{"fg/src/a"},
+			rels: []string{"a"},
+		},
+		{
+			name: "filegroup in namespace",
+			bp: `
+			test {
+				name: "foo",
+				srcs: ["//ns:a"],
+			}`,
+			srcs: []string{"ns/src/e/e"},
+			rels: []string{"e/e"},
+		},
+		{
+			name: "filegroups with the same name in different namespaces",
+			bp: `
+			test {
+				name: "foo",
+				srcs: [":a", "//ns:a"],
+			}`,
+			srcs: []string{"fg/src/a", "ns/src/e/e"},
+			rels: []string{"src/a", "e/e"},
+		},
+		{
+			name: "filegroups with the same name in different namespaces reversed",
+			bp: `
+			test {
+				name: "foo",
+				srcs: ["//ns:a", ":a"],
+			}`,
+			srcs: []string{"ns/src/e/e", "fg/src/a"},
+			rels: []string{"e/e", "src/a"},
+		},
+		{
+			name: "output file provider",
+			bp: `
+			test {
+				name: "foo",
+				srcs: [":b"],
+			}`,
+			srcs: []string{"fg/src/b"},
+			rels: []string{"src/b"},
+		},
+		{
+			name: "output file provider tagged",
+			bp: `
+			test {
+				name: "foo",
+				srcs: [":b{.tagged}"],
+			}`,
+			srcs: []string{"fg/src/c"},
+			rels: []string{"src/c"},
+		},
 		{
 			name: "special characters glob",
 			bp: `
@@ -0,0 +1,204 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// The prebuilt_radio_image and prebuilt_bootloader_image module types copy the prebuilt
+// radio (baseband) and bootloader images of a device into $PRODUCT_OUT as radio.img and
+// bootloader.img, where fastboot flashall and the updatepackage target look for them.  The
+// images are exported to Make as BOARD_RADIO_IMAGE and BOARD_BOOTLOADER_IMAGE, with their
+// versions in BOARD_RADIO_VERSION and BOARD_BOOTLOADER_VERSION for board-info.txt.
+
+func init() {
+	RegisterModuleType("prebuilt_radio_image", PrebuiltRadioImageFactory)
+	RegisterModuleType("prebuilt_bootloader_image", PrebuiltBootloaderImageFactory)
+
+	RegisterSingletonType("prebuilt_images", prebuiltImagesSingletonFactory)
+}
+
+type prebuiltImageProperties struct {
+	// Source file of the image.
+	Src *string `android:"path,arch_variant"`
+
+	// Version of the image, as reported by the device.  Must match the BasebandVersion or
+	// BootloaderVersion product variable if it is set.
+	Version *string
+}
+
+type prebuiltImageKind struct {
+	// The name of the image in $PRODUCT_OUT, without the .img extension.
+	name string
+
+	// The prefix of the make variables exported for the image.
+	makeVarPrefix string
+
+	// Returns the expected version of the image from the product config, or "".
+	expectedVersion func(DeviceConfig) string
+	versionVariable string
+}
+
+var (
+	radioImageKind = prebuiltImageKind{
+		name:            "radio",
+		makeVarPrefix:   "BOARD_RADIO",
+		expectedVersion: DeviceConfig.BasebandVersion,
+		versionVariable: "BasebandVersion",
+	}
+
+	bootloaderImageKind = prebuiltImageKind{
+		name:            "bootloader",
+		makeVarPrefix:   "BOARD_BOOTLOADER",
+		expectedVersion: DeviceConfig.BootloaderVersion,
+		versionVariable: "BootloaderVersion",
+	}
+
+	prebuiltImageKinds = []prebuiltImageKind{radioImageKind, bootloaderImageKind}
+)
+
+type PrebuiltImage struct {
+	ModuleBase
+
+	properties prebuiltImageProperties
+
+	kind prebuiltImageKind
+
+	outputFilePath OutputPath
+}
+
+func (p *PrebuiltImage) DepsMutator(ctx BottomUpMutatorContext) {
+	if p.properties.Src == nil {
+		ctx.PropertyErrorf("src", "missing prebuilt source file")
+	}
+	if String(p.properties.Version) == "" {
+		ctx.PropertyErrorf("version", "missing image version")
+	}
+}
+
+func (p *PrebuiltImage) GenerateAndroidBuildActions(ctx ModuleContext) {
+	version := String(p.properties.Version)
+	if expected := p.kind.expectedVersion(ctx.DeviceConfig()); expected != "" && version != expected {
+		ctx.PropertyErrorf("version", "%q does not match the %s product variable %q",
+			version, p.kind.versionVariable, expected)
+		return
+	}
+
+	p.outputFilePath = PathForOutput(ctx, "target", "product", ctx.Config().DeviceName(),
+		p.kind.name+".img")
+
+	ctx.Build(pctx, BuildParams{
+		Rule:        Cp,
+		Description: "copy " + p.outputFilePath.Base(),
+		Output:      p.outputFilePath,
+		Input:       PathForModuleSrc(ctx, String(p.properties.Src)),
+	})
+}
+
+// OutputFile returns the path of the image in $PRODUCT_OUT.
+func (p *PrebuiltImage) OutputFile() OutputPath {
+	return p.outputFilePath
+}
+
+// Version returns the version of the image.
+func (p *PrebuiltImage) Version() string {
+	return String(p.properties.Version)
+}
+
+func (p *PrebuiltImage) AndroidMk() AndroidMkData {
+	// The image is exported to Make through the BOARD_* make variables instead.
+	return AndroidMkData{
+		Disabled: true,
+	}
+}
+
+func newPrebuiltImage(kind prebuiltImageKind) Module {
+	module := &PrebuiltImage{kind: kind}
+	module.AddProperties(&module.properties)
+	InitAndroidArchModule(module, DeviceSupported, MultilibFirst)
+	return module
+}
+
+// prebuilt_radio_image is a prebuilt radio (baseband) image that is copied to
+// $(PRODUCT_OUT)/radio.img.
+func PrebuiltRadioImageFactory() Module {
+	return newPrebuiltImage(radioImageKind)
+}
+
+// prebuilt_bootloader_image is a prebuilt bootloader image that is copied to
+// $(PRODUCT_OUT)/bootloader.img.
+func PrebuiltBootloaderImageFactory() Module {
+	return newPrebuiltImage(bootloaderImageKind)
+}
+
+func prebuiltImagesSingletonFactory() Singleton {
+	return &prebuiltImagesSingleton{}
+}
+
+type prebuiltImagesSingleton struct {
+	images map[string]*PrebuiltImage
+}
+
+func (s *prebuiltImagesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	s.images = make(map[string]*PrebuiltImage)
+
+	var duplicates []string
+	ctx.VisitAllModules(func(m Module) {
+		image, ok := m.(*PrebuiltImage)
+		if !ok || !image.Enabled() || image.outputFilePath.String() == "" {
+			return
+		}
+		if other, exists := s.images[image.kind.name]; exists {
+			duplicates = append(duplicates, image.kind.name+".img: "+ctx.ModuleName(other)+" and "+
+				ctx.ModuleName(image))
+			return
+		}
+		s.images[image.kind.name] = image
+	})
+
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		ctx.Errorf("multiple prebuilt image modules for the same image:\n    %s",
+			strings.Join(duplicates, "\n    "))
+		return
+	}
+
+	var images Paths
+	for _, kind := range prebuiltImageKinds {
+		if image, ok := s.images[kind.name]; ok {
+			images = append(images, image.OutputFile())
+		}
+	}
+	if len(images) > 0 {
+		ctx.Build(pctx, BuildParams{
+			Rule:      blueprint.Phony,
+			Output:    PathForPhony(ctx, "prebuilt-images"),
+			Implicits: images,
+		})
+	}
+}
+
+func (s *prebuiltImagesSingleton) MakeVars(ctx MakeVarsContext) {
+	for _, kind := range prebuiltImageKinds {
+		if image, ok := s.images[kind.name]; ok {
+			ctx.Strict(kind.makeVarPrefix+"_IMAGE", image.OutputFile().String())
+			ctx.Strict(kind.makeVarPrefix+"_VERSION", image.Version())
+		}
+	}
+}
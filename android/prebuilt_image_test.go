@@ -0,0 +1,121 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testPrebuiltImage(t *testing.T, bp string, bootloaderVersion string) (*TestContext, Config, []error) {
+	t.Helper()
+
+	buildDir, err := ioutil.TempDir("", "soong_prebuilt_image_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	config := TestArchConfig(buildDir, nil)
+	if bootloaderVersion != "" {
+		config.TestProductVariables.BootloaderVersion = StringPtr(bootloaderVersion)
+	}
+
+	ctx := NewTestArchContext()
+	ctx.RegisterModuleType("prebuilt_radio_image", ModuleFactoryAdaptor(PrebuiltRadioImageFactory))
+	ctx.RegisterModuleType("prebuilt_bootloader_image", ModuleFactoryAdaptor(PrebuiltBootloaderImageFactory))
+	ctx.RegisterSingletonType("prebuilt_images", SingletonFactoryAdaptor(prebuiltImagesSingletonFactory))
+	ctx.Register()
+	ctx.MockFileSystem(map[string][]byte{
+		"Android.bp":     []byte(bp),
+		"radio.img":      nil,
+		"bootloader.img": nil,
+	})
+
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	return ctx, config, errs
+}
+
+func TestPrebuiltImage(t *testing.T) {
+	ctx, _, errs := testPrebuiltImage(t, `
+		prebuilt_radio_image {
+			name: "radio",
+			src: "radio.img",
+			version: "g1234",
+		}
+
+		prebuilt_bootloader_image {
+			name: "bootloader",
+			src: "bootloader.img",
+			version: "b-5678",
+		}
+	`, "b-5678")
+	FailIfErrored(t, errs)
+
+	radio := ctx.ModuleForTests("radio", "android_arm64_armv8-a").Module().(*PrebuiltImage)
+	if g, w := radio.OutputFile().Rel(), "target/product/test_device/radio.img"; g != w {
+		t.Errorf("expected radio image output %q, got %q", w, g)
+	}
+
+	phony := ctx.SingletonForTests("prebuilt_images").Output("prebuilt-images")
+	if len(phony.Implicits) != 2 {
+		t.Errorf("expected prebuilt-images to depend on both images, got %v", phony.Implicits)
+	}
+}
+
+func TestPrebuiltImageErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		bp    string
+		error string
+	}{
+		{
+			name: "version mismatch",
+			bp: `
+				prebuilt_bootloader_image {
+					name: "bootloader",
+					src: "bootloader.img",
+					version: "b-0000",
+				}`,
+			error: `does not match the BootloaderVersion product variable "b-5678"`,
+		},
+		{
+			name: "duplicate",
+			bp: `
+				prebuilt_bootloader_image {
+					name: "bootloader",
+					src: "bootloader.img",
+					version: "b-5678",
+				}
+
+				prebuilt_bootloader_image {
+					name: "bootloader2",
+					src: "bootloader.img",
+					version: "b-5678",
+				}`,
+			error: `multiple prebuilt image modules for the same image`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, errs := testPrebuiltImage(t, test.bp, "b-5678")
+			FailIfNoMatchingErrors(t, test.error, errs)
+		})
+	}
+}
@@ -71,6 +71,16 @@ func GetProtoFlags(ctx ModuleContext, p *ProtoProperties) ProtoFlags {
 		flags = append(flags, JoinWithPrefix(rootProtoIncludeDirs.Strings(), "-I"))
 	}
 
+	// Filegroups with a path property export it as an include directory for their .proto files,
+	// so that imports relative to the path can be resolved.
+	ctx.VisitDirectDeps(func(dep Module) {
+		if fg, ok := dep.(*fileGroup); ok && IsSourceDepTag(ctx.OtherModuleDependencyTag(dep)) {
+			if fg.protoIncludeDir.Valid() {
+				flags = append(flags, "-I"+fg.protoIncludeDir.String())
+			}
+		}
+	})
+
 	ctx.VisitDirectDepsWithTag(ProtoPluginDepTag, func(dep Module) {
 		if hostTool, ok := dep.(HostToolProvider); !ok || !hostTool.HostToolPath().Valid() {
 			ctx.PropertyErrorf("proto.plugin", "module %q is not a host tool provider",
@@ -99,10 +109,15 @@ func GetProtoFlags(ctx ModuleContext, p *ProtoProperties) ProtoFlags {
 
 type ProtoProperties struct {
 	Proto struct {
-		// Proto generator type.  C++: full or lite.  Java: micro, nano, stream, or lite.
+		// Proto generator type.  C++: full, lite, or one of the nanopb-c variants.  Java: full,
+		// lite, micro, nano, or stream.  When unset, code is generated without the lite option
+		// but the lite runtime library is linked.
 		Type *string `android:"arch_variant"`
 
-		// Proto plugin to use as the generator.  Must be a cc_binary_host module.
+		// Proto plugin to use as the generator instead of one of the built-in types.  The
+		// plugin is the host tool module named "protoc-gen-<plugin>", and its output is
+		// requested with --<plugin>_out.  No protobuf runtime library is added when a plugin
+		// is used.
 		Plugin *string `android:"arch_variant"`
 
 		// list of directories that will be added to the protoc include paths.
@@ -25,6 +25,13 @@ type moduleType struct {
 
 var moduleTypes []moduleType
 
+type moduleTypeLoadHook struct {
+	moduleType string
+	hook       func(LoadHookContext)
+}
+
+var moduleTypeLoadHooks []moduleTypeLoadHook
+
 type singleton struct {
 	name    string
 	factory blueprint.SingletonFactory
@@ -69,6 +76,37 @@ func RegisterModuleType(name string, factory ModuleFactory) {
 	moduleTypes = append(moduleTypes, moduleType{name, factory})
 }
 
+// RegisterLoadHook registers a load hook that is run on every module of the given module type,
+// after any load hooks added by the module type itself.  It is intended for Go packages outside
+// of build/soong, for example under vendor/*/build/soong, that need to append or prepend
+// properties to modules of an existing module type without patching it.  It must be called from
+// an init function.
+func RegisterLoadHook(moduleType string, hook func(LoadHookContext)) {
+	moduleTypeLoadHooks = append(moduleTypeLoadHooks, moduleTypeLoadHook{moduleType, hook})
+}
+
+// withModuleTypeLoadHooks returns a factory that adds the load hooks registered for moduleType
+// with RegisterLoadHook to each module created by factory.
+func withModuleTypeLoadHooks(moduleType string, factory ModuleFactory) ModuleFactory {
+	var hooks []func(LoadHookContext)
+	for _, h := range moduleTypeLoadHooks {
+		if h.moduleType == moduleType {
+			hooks = append(hooks, h.hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return factory
+	}
+
+	return func() Module {
+		module := factory()
+		for _, hook := range hooks {
+			AddLoadHook(module, hook)
+		}
+		return module
+	}
+}
+
 func RegisterSingletonType(name string, factory SingletonFactory) {
 	singletons = append(singletons, singleton{name, SingletonFactoryAdaptor(factory)})
 }
@@ -91,7 +129,7 @@ func (ctx *Context) Register() {
 	}
 
 	for _, t := range moduleTypes {
-		ctx.RegisterModuleType(t.name, ModuleFactoryAdaptor(t.factory))
+		ctx.RegisterModuleType(t.name, ModuleFactoryAdaptor(withModuleTypeLoadHooks(t.name, t.factory)))
 	}
 
 	for _, t := range singletons {
@@ -242,8 +242,43 @@ func (r *RuleBuilder) depFileMergerCmd(ctx PathContext, depFiles WritablePaths)
 		Flags(depFiles.Strings())
 }
 
+// validationCmd returns a command that checks, after the other commands of the rule ran, that the declared outputs
+// exist and that the depfile, if any, only lists existing files and generated files that are declared inputs.
+func (r *RuleBuilder) validationCmd(ctx PathContext, depFile WritablePath) *RuleBuilderCommand {
+	return RuleValidationCmd(ctx, depFile, r.Outputs(), r.Inputs())
+}
+
+// RuleValidationCmd returns a command that validates the outputs and depfile of a rule as configured by the
+// RuleValidation product variable, see RuleBuilder.Build.  depFile may be nil.  It is used by rules that are not
+// built with RuleBuilder, like genrule.
+func RuleValidationCmd(ctx PathContext, depFile WritablePath, outputs WritablePaths, inputs Paths) *RuleBuilderCommand {
+	buildDir := ctx.Config().BuildDir()
+
+	cmd := (&RuleBuilderCommand{}).
+		Tool(ctx.Config().HostToolPath(ctx, "dep_fixer")).
+		Flag("-validate")
+	if ctx.Config().RuleValidation() == RuleValidationStrict {
+		cmd.Flag("-strict")
+	}
+	cmd.FlagWithArg("-out_dir ", buildDir)
+	for _, output := range outputs {
+		cmd.FlagWithArg("-output ", output.String())
+	}
+	for _, input := range inputs {
+		if strings.HasPrefix(input.String(), buildDir+"/") {
+			cmd.FlagWithArg("-declared_input ", input.String())
+		}
+	}
+	if depFile != nil {
+		cmd.Flag(depFile.String())
+	}
+	return cmd
+}
+
 // Build adds the built command line to the build graph, with dependencies on Inputs and Tools, and output files for
-// Outputs.
+// Outputs.  If the RuleValidation product variable is set, the rule also checks that the outputs were created and that
+// the depfile doesn't reference missing files or generated files that are not inputs of the rule, printing warnings
+// or, in strict mode, failing.
 func (r *RuleBuilder) Build(pctx PackageContext, ctx BuilderContext, name string, desc string) {
 	name = ninjaNameEscape(name)
 
@@ -275,6 +310,13 @@ func (r *RuleBuilder) Build(pctx PackageContext, ctx BuilderContext, name string
 		}
 	}
 
+	if ctx.Config().RuleValidation() != "" && len(commands) > 0 {
+		// Add a command that validates the outputs and depfile after the other commands ran.
+		cmd := r.validationCmd(ctx, depFile)
+		commands = append(commands, string(cmd.buf))
+		tools = append(tools, cmd.tools...)
+	}
+
 	// Ninja doesn't like multiple outputs when depfiles are enabled, move all but the first output to
 	// ImplicitOutputs.  RuleBuilder never uses "$out", so the distinction between Outputs and ImplicitOutputs
 	// doesn't matter.
@@ -318,6 +318,30 @@ func TestRuleBuilder(t *testing.T) {
 	}
 }
 
+func TestRuleBuilder_Validation(t *testing.T) {
+	config := TestConfig("out", nil)
+	config.TestProductVariables.RuleValidation = StringPtr(RuleValidationStrict)
+	ctx := PathContextForTesting(config, map[string][]byte{
+		"dep_fixer": nil,
+		"input":     nil,
+	})
+
+	rule := NewRuleBuilder()
+	rule.Command().
+		Text("gen").
+		Input(PathForSource(ctx, "input")).
+		Input(PathForOutput(ctx, "generated")).
+		Output(PathForOutput(ctx, "output")).
+		DepFile(PathForOutput(ctx, "output.d"))
+
+	want := "out/host/" + ctx.Config().PrebuiltOS() + "/bin/dep_fixer -validate -strict -out_dir out" +
+		" -output out/output -declared_input out/generated out/output.d"
+
+	if g := rule.validationCmd(ctx, PathForOutput(ctx, "output.d")).String(); g != want {
+		t.Errorf("\nwant rule.validationCmd() = %#v\n                    got %#v", want, g)
+	}
+}
+
 func testRuleBuilderFactory() Module {
 	module := &testRuleBuilderModule{}
 	module.AddProperties(&module.properties)
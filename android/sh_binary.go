@@ -30,6 +30,7 @@ func init() {
 	RegisterModuleType("sh_binary", ShBinaryFactory)
 	RegisterModuleType("sh_binary_host", ShBinaryHostFactory)
 	RegisterModuleType("sh_test", ShTestFactory)
+	RegisterModuleType("sh_binary_defaults", ShBinaryDefaultsFactory)
 }
 
 type shBinaryProperties struct {
@@ -62,6 +63,7 @@ type TestProperties struct {
 
 type ShBinary struct {
 	ModuleBase
+	DefaultableModuleBase
 
 	properties shBinaryProperties
 
@@ -73,6 +75,8 @@ type ShTest struct {
 	ShBinary
 
 	testProperties TestProperties
+
+	testConfig Path
 }
 
 func (s *ShBinary) DepsMutator(ctx BottomUpMutatorContext) {
@@ -137,6 +141,27 @@ func (s *ShBinary) AndroidMk() AndroidMkData {
 	}
 }
 
+func (s *ShTest) GenerateAndroidBuildActions(ctx ModuleContext) {
+	s.ShBinary.GenerateAndroidBuildActions(ctx)
+
+	if config := String(s.testProperties.Test_config); config != "" {
+		s.testConfig = PathForModuleSrc(ctx, config)
+	}
+}
+
+func (s *ShTest) TestSuites() []string {
+	return s.testProperties.Test_suites
+}
+
+func (s *ShTest) TestSuiteInfo() TestSuiteInfo {
+	return TestSuiteInfo{
+		Output: s.outputFilePath,
+		Config: s.testConfig,
+	}
+}
+
+var _ TestSuiteModule = (*ShTest)(nil)
+
 func (s *ShTest) AndroidMk() AndroidMkData {
 	data := s.ShBinary.AndroidMk()
 	data.Class = "NATIVE_TESTS"
@@ -158,6 +183,7 @@ func ShBinaryFactory() Module {
 	module := &ShBinary{}
 	InitShBinaryModule(module)
 	InitAndroidArchModule(module, HostAndDeviceSupported, MultilibFirst)
+	InitDefaultableModule(module)
 	return module
 }
 
@@ -167,6 +193,7 @@ func ShBinaryHostFactory() Module {
 	module := &ShBinary{}
 	InitShBinaryModule(module)
 	InitAndroidArchModule(module, HostSupported, MultilibFirst)
+	InitDefaultableModule(module)
 	return module
 }
 
@@ -176,5 +203,15 @@ func ShTestFactory() Module {
 	module.AddProperties(&module.testProperties)
 
 	InitAndroidArchModule(module, HostAndDeviceSupported, MultilibFirst)
+	InitDefaultableModule(module)
+	return module
+}
+
+// sh_binary_defaults provides a set of properties that can be inherited by other sh_binary,
+// sh_binary_host and sh_test modules.
+func ShBinaryDefaultsFactory() Module {
+	module := &defaultsModule{}
+	module.AddProperties(&shBinaryProperties{}, &TestProperties{})
+	InitDefaultsModule(module)
 	return module
 }
@@ -0,0 +1,200 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The test_suites singleton packages the Soong-defined members of the general-tests and
+// device-tests suites into zip files, using the same layout as the Make compatibility suite
+// packaging: <host|target>/testcases/<module>/[<arch>/]<files>.  Other suites (cts, vts, ...)
+// are still packaged by Make.
+
+func init() {
+	RegisterSingletonType("test_suites", TestSuitesSingleton)
+}
+
+// packagedTestSuites lists the test suites that are packaged by Soong.
+var packagedTestSuites = []string{"general-tests", "device-tests"}
+
+// TestSuiteInfo describes the files that make up a test module in a test suite.
+type TestSuiteInfo struct {
+	// The test executable or jar, packaged into the module's directory under its base name.
+	Output Path
+
+	// Data files, packaged next to Output using their paths relative to the module directory.
+	Data Paths
+
+	// The test config, packaged into the module's directory as <module>.config.  May be nil.
+	Config Path
+
+	// Shared libraries the test loads at runtime, packaged into <host|target>/testcases/lib[64]
+	// where the test's ../../lib[64] rpath finds them.
+	SharedLibs Paths
+}
+
+// TestSuiteModule is implemented by test modules that can be packaged into a test suite.
+type TestSuiteModule interface {
+	Module
+
+	// TestSuites returns the list of test suites the module is a member of.
+	TestSuites() []string
+
+	// TestSuiteInfo returns the files to package for the module.  Output is nil if the
+	// module has nothing to package.
+	TestSuiteInfo() TestSuiteInfo
+}
+
+func TestSuitesSingleton() Singleton {
+	return &testSuitesSingleton{}
+}
+
+type testSuitesSingleton struct {
+	zips    map[string]WritablePath
+	modules map[string][]string
+}
+
+// testSuiteModuleDir returns the directory of a module variant inside a test suite zip.
+func testSuiteModuleDir(ctx SingletonContext, m Module) string {
+	class := "target"
+	if m.Target().Os.Class != Device {
+		class = "host"
+	}
+	return filepath.Join(class, "testcases", ctx.ModuleName(m))
+}
+
+func (s *testSuitesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	suiteModules := make(map[string][]TestSuiteModule)
+
+	ctx.VisitAllModules(func(m Module) {
+		tsm, ok := m.(TestSuiteModule)
+		if !ok || !m.Enabled() {
+			return
+		}
+		if tsm.TestSuiteInfo().Output == nil {
+			return
+		}
+		for _, suite := range tsm.TestSuites() {
+			if InList(suite, packagedTestSuites) {
+				suiteModules[suite] = append(suiteModules[suite], tsm)
+			}
+		}
+	})
+
+	s.zips = make(map[string]WritablePath)
+	s.modules = make(map[string][]string)
+
+	for _, suite := range packagedTestSuites {
+		s.packageTestSuite(ctx, suite, suiteModules[suite])
+	}
+}
+
+func (s *testSuitesSingleton) packageTestSuite(ctx SingletonContext, suite string, modules []TestSuiteModule) {
+	stagingDir := PathForOutput(ctx, "packaging", suite)
+	zip := PathForOutput(ctx, "packaging", suite+".zip")
+	list := PathForOutput(ctx, "packaging", suite+"_list")
+
+	rule := NewRuleBuilder()
+	rule.Command().Text("rm -rf").Flag(stagingDir.String())
+
+	var listing []string
+	var moduleNames []string
+	sources := make(map[string]Path)
+	copyFile := func(src Path, dest string) {
+		if prev, ok := sources[dest]; ok {
+			if prev.String() != src.String() {
+				ctx.Errorf("%s: %q and %q are both packaged as %q", suite, prev, src, dest)
+			}
+			return
+		}
+		sources[dest] = src
+		listing = append(listing, dest)
+		destPath := filepath.Join(stagingDir.String(), dest)
+		rule.Command().Text("mkdir -p").Flag(filepath.Dir(destPath))
+		rule.Command().Text("cp -f").Input(src).Flag(destPath)
+	}
+
+	for _, m := range modules {
+		info := m.TestSuiteInfo()
+		moduleDir := testSuiteModuleDir(ctx, m)
+		moduleNames = append(moduleNames, ctx.ModuleName(m))
+
+		archDir := moduleDir
+		if arch := m.Target().Arch.ArchType; arch != Common {
+			archDir = filepath.Join(moduleDir, arch.String())
+		}
+
+		copyFile(info.Output, filepath.Join(archDir, info.Output.Base()))
+		for _, data := range info.Data {
+			copyFile(data, filepath.Join(archDir, data.Rel()))
+		}
+		if info.Config != nil {
+			copyFile(info.Config, filepath.Join(moduleDir, ctx.ModuleName(m)+".config"))
+		}
+		if len(info.SharedLibs) > 0 {
+			libDir := "lib"
+			if m.Target().Arch.ArchType.Multilib == "lib64" {
+				libDir = "lib64"
+			}
+			libDir = filepath.Join(filepath.Dir(moduleDir), libDir)
+			for _, lib := range info.SharedLibs {
+				copyFile(lib, filepath.Join(libDir, lib.Base()))
+			}
+		}
+	}
+
+	rule.Command().Text("mkdir -p").Flag(stagingDir.String())
+	rule.Command().
+		Tool(ctx.Config().HostToolPath(ctx, "soong_zip")).
+		FlagWithOutput("-o ", zip).
+		FlagWithArg("-C ", stagingDir.String()).
+		FlagWithArg("-D ", stagingDir.String())
+	rule.Command().Text("rm -rf").Flag(stagingDir.String())
+
+	rule.Build(pctx, ctx, strings.Replace(suite, "-", "_", -1)+"_zip", "package "+suite+".zip")
+
+	sort.Strings(listing)
+	ctx.Build(pctx, BuildParams{
+		Rule:        WriteFile,
+		Description: "generate " + list.Base(),
+		Output:      list,
+		Args: map[string]string{
+			"content": strings.Join(listing, "\\n"),
+		},
+	})
+
+	s.zips[suite] = zip
+	s.modules[suite] = FirstUniqueStrings(moduleNames)
+}
+
+// testSuiteMakeVarPrefix converts a suite name like general-tests to SOONG_GENERAL_TESTS.
+func testSuiteMakeVarPrefix(suite string) string {
+	return "SOONG_" + strings.ToUpper(strings.Replace(suite, "-", "_", -1))
+}
+
+func (s *testSuitesSingleton) MakeVars(ctx MakeVarsContext) {
+	for _, suite := range packagedTestSuites {
+		if _, ok := s.zips[suite]; !ok {
+			continue
+		}
+		prefix := testSuiteMakeVarPrefix(suite)
+		ctx.Strict(prefix+"_ZIP", s.zips[suite].String())
+		ctx.Strict(prefix+"_LIST", PathForOutput(ctx, "packaging", suite+"_list").String())
+		ctx.Strict(prefix+"_MODULES", strings.Join(s.modules[suite], " "))
+	}
+}
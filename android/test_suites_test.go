@@ -0,0 +1,196 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTestSuites(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "soong_test_suites_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	bp := `
+		sh_test {
+			name: "foo",
+			src: "test.sh",
+			test_config: "foo.xml",
+			test_suites: ["general-tests"],
+		}
+
+		sh_test {
+			name: "bar",
+			src: "test.sh",
+			test_suites: ["cts"],
+		}
+	`
+
+	config := TestArchConfig(buildDir, nil)
+	ctx := NewTestArchContext()
+	ctx.MockFileSystem(map[string][]byte{
+		"Android.bp": []byte(bp),
+		"test.sh":    nil,
+		"foo.xml":    nil,
+	})
+	ctx.RegisterModuleType("sh_test", ModuleFactoryAdaptor(ShTestFactory))
+	ctx.RegisterSingletonType("test_suites", SingletonFactoryAdaptor(TestSuitesSingleton))
+	ctx.Register()
+
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	FailIfErrored(t, errs)
+
+	suites := ctx.SingletonForTests("test_suites")
+
+	list := suites.Output("packaging/general-tests_list").Args["content"]
+	expectedList := []string{
+		"target/testcases/foo/arm64/foo",
+		"target/testcases/foo/foo.config",
+	}
+	if g, w := list, strings.Join(expectedList, "\\n"); g != w {
+		t.Errorf("expected general-tests listing %q, got %q", w, g)
+	}
+
+	zip := suites.Output("packaging/general-tests.zip")
+	if !strings.Contains(zip.RuleParams.Command, "foo.xml") {
+		t.Errorf("expected test config foo.xml to be packaged in %q", zip.RuleParams.Command)
+	}
+
+	if g := suites.Output("packaging/device-tests_list").Args["content"]; g != "" {
+		t.Errorf("expected empty device-tests listing, got %q", g)
+	}
+}
+
+type testSuiteTestModule struct {
+	ModuleBase
+	props struct {
+		Test_suites []string
+		Shared_libs []string `android:"path"`
+	}
+
+	info TestSuiteInfo
+}
+
+func testSuiteTestModuleFactory() Module {
+	module := &testSuiteTestModule{}
+	module.AddProperties(&module.props)
+	InitAndroidArchModule(module, HostSupported, MultilibFirst)
+	return module
+}
+
+func (m *testSuiteTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	m.info = TestSuiteInfo{
+		Output:     PathForModuleOut(ctx, ctx.ModuleName()),
+		SharedLibs: PathsForModuleSrc(ctx, m.props.Shared_libs),
+	}
+}
+
+func (m *testSuiteTestModule) TestSuites() []string {
+	return m.props.Test_suites
+}
+
+func (m *testSuiteTestModule) TestSuiteInfo() TestSuiteInfo {
+	return m.info
+}
+
+func TestTestSuitesSharedLibs(t *testing.T) {
+	tests := []struct {
+		name string
+		bp   string
+		list []string
+		err  string
+	}{
+		{
+			name: "shared",
+			bp: `
+				test {
+					name: "foo",
+					shared_libs: ["a/libfoo.so"],
+					test_suites: ["general-tests"],
+				}
+
+				test {
+					name: "bar",
+					shared_libs: ["a/libfoo.so"],
+					test_suites: ["general-tests"],
+				}
+			`,
+			list: []string{
+				"host/testcases/bar/x86_64/bar",
+				"host/testcases/foo/x86_64/foo",
+				"host/testcases/lib64/libfoo.so",
+			},
+		},
+		{
+			name: "conflict",
+			bp: `
+				test {
+					name: "foo",
+					shared_libs: ["a/libfoo.so"],
+					test_suites: ["general-tests"],
+				}
+
+				test {
+					name: "bar",
+					shared_libs: ["b/libfoo.so"],
+					test_suites: ["general-tests"],
+				}
+			`,
+			err: `general-tests: "[ab]/libfoo.so" and "[ab]/libfoo.so" are both packaged as "host/testcases/lib64/libfoo.so"`,
+		},
+	}
+
+	buildDir, err := ioutil.TempDir("", "soong_test_suites_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestArchConfig(buildDir, nil)
+			ctx := NewTestArchContext()
+			ctx.MockFileSystem(map[string][]byte{
+				"Android.bp":  []byte(test.bp),
+				"a/libfoo.so": nil,
+				"b/libfoo.so": nil,
+			})
+			ctx.RegisterModuleType("test", ModuleFactoryAdaptor(testSuiteTestModuleFactory))
+			ctx.RegisterSingletonType("test_suites", SingletonFactoryAdaptor(TestSuitesSingleton))
+			ctx.Register()
+
+			_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+			FailIfErrored(t, errs)
+			_, errs = ctx.PrepareBuildActions(config)
+			if test.err != "" {
+				FailIfNoMatchingErrors(t, test.err, errs)
+				return
+			}
+			FailIfErrored(t, errs)
+
+			list := ctx.SingletonForTests("test_suites").Output("packaging/general-tests_list").Args["content"]
+			if g, w := list, strings.Join(test.list, "\\n"); g != w {
+				t.Errorf("expected general-tests listing %q, got %q", w, g)
+			}
+		})
+	}
+}
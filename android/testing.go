@@ -369,3 +369,18 @@ func FailIfNoMatchingErrors(t *testing.T, pattern string, errs []error) {
 		}
 	}
 }
+
+// AndroidMkEntriesForTest returns the AndroidMkEntries of mod with the common entries filled in,
+// as they would be written to the Make makefile.
+func AndroidMkEntriesForTest(t *testing.T, config Config, bpPath string, mod blueprint.Module) []AndroidMkEntries {
+	t.Helper()
+	provider, ok := mod.(AndroidMkEntriesProvider)
+	if !ok {
+		t.Fatalf("module %q does not implement AndroidMkEntriesProvider", mod.Name())
+	}
+	entriesList := provider.AndroidMkEntries()
+	for i := range entriesList {
+		entriesList[i].fillInEntries(config, bpPath, mod)
+	}
+	return entriesList
+}
@@ -141,6 +141,11 @@ type productVariables struct {
 	BuildId             *string `json:",omitempty"`
 	BuildNumberFromFile *string `json:",omitempty"`
 	DateFromFile        *string `json:",omitempty"`
+	BuildFingerprint    *string `json:",omitempty"`
+	BuildVersionTags    *string `json:",omitempty"`
+
+	ProductBrand *string `json:",omitempty"`
+	ProductName  *string `json:",omitempty"`
 
 	Platform_version_name                     *string  `json:",omitempty"`
 	Platform_sdk_version                      *int     `json:",omitempty"`
@@ -209,6 +214,8 @@ type productVariables struct {
 
 	Check_elf_files *bool `json:",omitempty"`
 
+	Strip_mini_debug_info *bool `json:",omitempty"`
+
 	UncompressPrivAppDex             *bool    `json:",omitempty"`
 	ModulesLoadedByPrivilegedModules []string `json:",omitempty"`
 
@@ -233,10 +240,23 @@ type productVariables struct {
 	ClangTidy  *bool   `json:",omitempty"`
 	TidyChecks *string `json:",omitempty"`
 
+	ClangVersion      *string `json:",omitempty"`
+	ClangShortVersion *string `json:",omitempty"`
+
 	NativeCoverage       *bool    `json:",omitempty"`
 	CoveragePaths        []string `json:",omitempty"`
 	CoverageExcludePaths []string `json:",omitempty"`
 
+	NativeSymbols *string `json:",omitempty"`
+
+	RuleValidation *string `json:",omitempty"`
+
+	BasebandVersion   *string `json:",omitempty"`
+	BootloaderVersion *string `json:",omitempty"`
+
+	JavaCoveragePaths        []string `json:",omitempty"`
+	JavaCoverageExcludePaths []string `json:",omitempty"`
+
 	DevicePrefer32BitApps        *bool `json:",omitempty"`
 	DevicePrefer32BitExecutables *bool `json:",omitempty"`
 	HostPrefer32BitExecutables   *bool `json:",omitempty"`
@@ -263,6 +283,11 @@ type productVariables struct {
 	NamespacesToExport []string `json:",omitempty"`
 
 	PgoAdditionalProfileDirs []string `json:",omitempty"`
+	PgoInstrument            []string `json:",omitempty"`
+	PgoNoProfileUse          *bool    `json:",omitempty"`
+
+	AfdoAdditionalProfileDirs []string `json:",omitempty"`
+	AfdoNoProfileUse          *bool    `json:",omitempty"`
 
 	VndkUseCoreVariant *bool `json:",omitempty"`
 
@@ -280,6 +305,9 @@ type productVariables struct {
 
 	DexpreoptGlobalConfig *string `json:",omitempty"`
 
+	DexpreoptBootImageProfiles []string `json:",omitempty"`
+	DexpreoptPreloadedClasses  *string  `json:",omitempty"`
+
 	ManifestPackageNameOverrides []string `json:",omitempty"`
 	CertificateOverrides         []string `json:",omitempty"`
 	PackageNameOverrides         []string `json:",omitempty"`
@@ -130,6 +130,7 @@ func init() {
 			"LOCAL_LDLIBS":                        "host_ldlibs",
 			"LOCAL_CLANG_CFLAGS":                  "clang_cflags",
 			"LOCAL_YACCFLAGS":                     "yaccflags",
+			"LOCAL_LEXFLAGS":                      "lexflags",
 			"LOCAL_SANITIZE_RECOVER":              "sanitize.recover",
 			"LOCAL_LOGTAGS_FILES":                 "logtags",
 			"LOCAL_EXPORT_HEADER_LIBRARY_HEADERS": "export_header_lib_headers",
@@ -936,6 +937,7 @@ var prebuiltTypes = map[string]string{
 	"EXECUTABLES":      "cc_prebuilt_binary",
 	"JAVA_LIBRARIES":   "java_import",
 	"ETC":              "prebuilt_etc",
+	"APPS":             "android_app_import",
 }
 
 var soongModuleTypes = map[string]bool{}
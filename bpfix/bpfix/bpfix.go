@@ -102,6 +102,10 @@ var fixSteps = []fixStep{
 		name: "rewriteAndroidTest",
 		fix:  rewriteAndroidTest,
 	},
+	{
+		name: "rewriteAndroidAppImport",
+		fix:  rewriteAndroidAppImport,
+	},
 }
 
 func NewFixRequest() FixRequest {
@@ -485,6 +489,29 @@ var localModulePathRewrite = map[string][]filenamePrefixToFlags{
 	"TARGET_RECOVERY_ROOT_OUT":        {{prefix: "/system/etc", flags: []string{"recovery"}}},
 }
 
+// convertToSingleSource converts the `srcs` attribute the rewriter creates from LOCAL_SRC_FILES to the
+// single source attribute srcPropertyName.  If the value of 'srcs' is a list, we can convert it only if
+// it contains a single element.
+func convertToSingleSource(mod *parser.Module, srcPropertyName string) {
+	if srcs, ok := mod.GetProperty("srcs"); ok {
+		if srcList, ok := srcs.Value.(*parser.List); ok {
+			removeProperty(mod, "srcs")
+			if len(srcList.Values) == 1 {
+				mod.Properties = append(mod.Properties,
+					&parser.Property{Name: srcPropertyName, NamePos: srcs.NamePos, ColonPos: srcs.ColonPos, Value: resolveLocalModule(mod, srcList.Values[0])})
+			} else if len(srcList.Values) > 1 {
+				indicateAttributeError(mod, srcPropertyName, "LOCAL_SRC_FILES should contain at most one item")
+			}
+		} else if _, ok = srcs.Value.(*parser.Variable); ok {
+			removeProperty(mod, "srcs")
+			mod.Properties = append(mod.Properties,
+				&parser.Property{Name: srcPropertyName, NamePos: srcs.NamePos, ColonPos: srcs.ColonPos, Value: resolveLocalModule(mod, srcs.Value)})
+		} else {
+			renameProperty(mod, "srcs", srcPropertyName)
+		}
+	}
+}
+
 // rewriteAndroidPrebuiltEtc fixes prebuilt_etc rule
 func rewriteAndroidmkPrebuiltEtc(f *Fixer) error {
 	for _, def := range f.tree.Defs {
@@ -498,26 +525,8 @@ func rewriteAndroidmkPrebuiltEtc(f *Fixer) error {
 		}
 
 		// The rewriter converts LOCAL_SRC_FILES to `srcs` attribute. Convert
-		// it to 'src' attribute (which is where the file is installed). If the
-		// value 'srcs' is a list, we can convert it only if it contains a single
-		// element.
-		if srcs, ok := mod.GetProperty("srcs"); ok {
-			if srcList, ok := srcs.Value.(*parser.List); ok {
-				removeProperty(mod, "srcs")
-				if len(srcList.Values) == 1 {
-					mod.Properties = append(mod.Properties,
-						&parser.Property{Name: "src", NamePos: srcs.NamePos, ColonPos: srcs.ColonPos, Value: resolveLocalModule(mod, srcList.Values[0])})
-				} else if len(srcList.Values) > 1 {
-					indicateAttributeError(mod, "src", "LOCAL_SRC_FILES should contain at most one item")
-				}
-			} else if _, ok = srcs.Value.(*parser.Variable); ok {
-				removeProperty(mod, "srcs")
-				mod.Properties = append(mod.Properties,
-					&parser.Property{Name: "src", NamePos: srcs.NamePos, ColonPos: srcs.ColonPos, Value: resolveLocalModule(mod, srcs.Value)})
-			} else {
-				renameProperty(mod, "srcs", "src")
-			}
-		}
+		// it to 'src' attribute (which is where the file is installed).
+		convertToSingleSource(mod, "src")
 
 		// The rewriter converts LOCAL_MODULE_PATH attribute into a struct attribute
 		// 'local_module_path'. Analyze its contents and create the correct sub_dir:,
@@ -565,6 +574,29 @@ func rewriteAndroidmkPrebuiltEtc(f *Fixer) error {
 	return nil
 }
 
+// rewriteAndroidAppImport fixes android_app_import modules converted from prebuilt APKs.
+func rewriteAndroidAppImport(f *Fixer) error {
+	for _, def := range f.tree.Defs {
+		mod, ok := def.(*parser.Module)
+		if !(ok && mod.Type == "android_app_import") {
+			continue
+		}
+		// The rewriter converts LOCAL_SRC_FILES to `srcs` attribute. Convert
+		// it to 'apk' attribute.
+		convertToSingleSource(mod, "apk")
+
+		// LOCAL_CERTIFICATE := PRESIGNED is expressed with the `presigned` attribute.
+		if prop, ok := mod.GetProperty("certificate"); ok {
+			if certificate, ok := prop.Value.(*parser.String); ok && certificate.Value == "PRESIGNED" {
+				removeProperty(mod, "certificate")
+				mod.Properties = append(mod.Properties,
+					&parser.Property{Name: "presigned", NamePos: prop.NamePos, ColonPos: prop.ColonPos, Value: &parser.Bool{Value: true, Token: "true"}})
+			}
+		}
+	}
+	return nil
+}
+
 func rewriteAndroidTest(f *Fixer) error {
 	for _, def := range f.tree.Defs {
 		mod, ok := def.(*parser.Module)
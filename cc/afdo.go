@@ -0,0 +1,135 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"fmt"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// AFDO (automatic feedback-directed optimization) uses sampled profiles collected from
+// production devices, rather than instrumented benchmark runs, to guide optimization.  Modules
+// opt in with "afdo: true" and pick up a checked-in profile named after the module from one of
+// the AFDO profile projects.
+
+var (
+	globalAfdoProfileProjects = []string{
+		"toolchain/pgo-profiles/sampling",
+		"vendor/google_data/pgo-profiles/sampling",
+	}
+)
+
+var afdoProfileProjectsConfigKey = android.NewOnceKey("AfdoProfileProjects")
+
+const afdoCFlagsFormat = "-fprofile-sample-accurate -fprofile-sample-use=%s"
+
+func getAfdoProfileProjects(config android.DeviceConfig) []string {
+	return config.OnceStringSlice(afdoProfileProjectsConfigKey, func() []string {
+		return append(globalAfdoProfileProjects, config.AfdoAdditionalProfileDirs()...)
+	})
+}
+
+func recordMissingAfdoProfileFile(ctx BaseModuleContext, missing string) {
+	getNamedMapForConfig(ctx.Config(), modulesMissingAfdoProfileFileKey).Store(missing, true)
+}
+
+type AfdoProperties struct {
+	// Whether to compile this module with a checked-in AFDO profile, if one is available.
+	Afdo *bool
+
+	AfdoCompile     bool   `blueprint:"mutated"`
+	AfdoProfileFile string `blueprint:"mutated"`
+}
+
+type afdo struct {
+	Properties AfdoProperties
+}
+
+func (afdo *afdo) props() []interface{} {
+	return []interface{}{&afdo.Properties}
+}
+
+// getAfdoProfileFileNames returns the profile file names to look for, most specific first.
+func getAfdoProfileFileNames(ctx BaseModuleContext) []string {
+	moduleName := ctx.ModuleName()
+	return []string{
+		moduleName + "_" + ctx.Arch().ArchType.String() + ".afdo",
+		moduleName + ".afdo",
+	}
+}
+
+func (props *AfdoProperties) getAfdoProfileFile(ctx BaseModuleContext) android.OptionalPath {
+	for _, profileFile := range getAfdoProfileFileNames(ctx) {
+		for _, profileProject := range getAfdoProfileProjects(ctx.DeviceConfig()) {
+			path := android.ExistentPathForSource(ctx, profileProject, profileFile)
+			if path.Valid() {
+				return path
+			}
+		}
+	}
+
+	// Record that this module's profile file is absent
+	missing := ctx.ModuleDir() + "/Android.bp:" + ctx.ModuleName()
+	recordMissingAfdoProfileFile(ctx, missing)
+
+	return android.OptionalPathForPath(nil)
+}
+
+func (afdo *afdo) begin(ctx BaseModuleContext) {
+	// AFDO profiles are collected on devices
+	if ctx.Host() {
+		return
+	}
+
+	if !proptools.Bool(afdo.Properties.Afdo) || !ctx.DeviceConfig().AfdoProfileUseEnabled() {
+		return
+	}
+
+	// Instrumented PGO builds and PGO profiles take precedence, mixing the two kinds of profiles
+	// is not supported.
+	if ctx.isPgoInstrumented() || ctx.isPgoCompile() {
+		return
+	}
+
+	if profileFile := afdo.Properties.getAfdoProfileFile(ctx); profileFile.Valid() {
+		afdo.Properties.AfdoCompile = true
+		afdo.Properties.AfdoProfileFile = profileFile.String()
+	}
+}
+
+func (afdo *afdo) flags(ctx ModuleContext, flags Flags) Flags {
+	if !afdo.Properties.AfdoCompile {
+		return flags
+	}
+
+	// The profile file was found by begin, which runs before the variants that reach here are
+	// created, so only its path is kept in the mutated properties.
+	profileFilePath := android.PathForSource(ctx, afdo.Properties.AfdoProfileFile)
+
+	profileUseFlag := fmt.Sprintf(afdoCFlagsFormat, profileFilePath.String())
+	flags.CFlags = append(flags.CFlags, profileUseFlag)
+	flags.LdFlags = append(flags.LdFlags, profileUseFlag)
+	flags.LdFlags = append(flags.LdFlags, "-Wl,-mllvm,-no-warn-sample-unused=true")
+
+	// Update CFlagsDeps and LdFlagsDeps so the module is rebuilt
+	// if profileFile gets updated
+	flags.CFlagsDeps = append(flags.CFlagsDeps, profileFilePath)
+	flags.LdFlagsDeps = append(flags.LdFlagsDeps, profileFilePath)
+
+	return flags
+}
@@ -26,6 +26,7 @@ import (
 var (
 	vendorSuffix   = ".vendor"
 	recoverySuffix = ".recovery"
+	ramdiskSuffix  = ".ramdisk"
 )
 
 type AndroidMkContext interface {
@@ -38,6 +39,7 @@ type AndroidMkContext interface {
 	useVndk() bool
 	static() bool
 	inRecovery() bool
+	inRamdisk() bool
 }
 
 type subAndroidMkProvider interface {
@@ -111,6 +113,8 @@ func (c *Module) AndroidMk() android.AndroidMkData {
 		ret.SubName += vendorSuffix
 	} else if c.inRecovery() && !c.onlyInRecovery() {
 		ret.SubName += recoverySuffix
+	} else if c.inRamdisk() && !c.onlyInRamdisk() {
+		ret.SubName += ramdiskSuffix
 	}
 
 	return ret
@@ -204,7 +208,7 @@ func (library *libraryDecorator) AndroidMk(ctx AndroidMkContext, ret *android.An
 		})
 	}
 	if len(library.Properties.Stubs.Versions) > 0 &&
-		android.DirectlyInAnyApex(ctx, ctx.Name()) && !ctx.inRecovery() && !ctx.useVndk() &&
+		android.DirectlyInAnyApex(ctx, ctx.Name()) && !ctx.inRecovery() && !ctx.inRamdisk() && !ctx.useVndk() &&
 		!ctx.static() {
 		if !library.buildStubs() {
 			ret.SubName = ".bootstrap"
@@ -253,6 +253,7 @@ type builderFlags struct {
 	ldFlags         string
 	libFlags        string
 	yaccFlags       string
+	lexFlags        string
 	tidyFlags       string
 	sAbiFlags       string
 	yasmFlags       string
@@ -19,6 +19,7 @@ package cc
 // is handled in builder.go
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -137,6 +138,7 @@ type Flags struct {
 	CppFlags        []string // Flags that apply to C++ source files
 	ToolingCppFlags []string // Flags that apply to C++ source files parsed by clang LibTooling tools
 	YaccFlags       []string // Flags that apply to Yacc source files
+	LexFlags        []string // Flags that apply to Lex source files
 	aidlFlags       []string // Flags that apply to aidl source files
 	rsFlags         []string // Flags that apply to renderscript source files
 	LdFlags         []string // Flags that apply to linker command lines
@@ -202,6 +204,11 @@ type BaseProperties struct {
 
 	InRecovery bool `blueprint:"mutated"`
 
+	// Make this module available when building for ramdisk
+	Ramdisk_available *bool
+
+	InRamdisk bool `blueprint:"mutated"`
+
 	// Allows this module to use non-APEX version of libraries. Useful
 	// for building binaries that are started before APEXes are activated.
 	Bootstrap *bool
@@ -251,11 +258,13 @@ type ModuleContextIntf interface {
 	isVndkSp() bool
 	isVndkExt() bool
 	inRecovery() bool
+	inRamdisk() bool
 	shouldCreateVndkSourceAbiDump() bool
 	selectedStl() string
 	baseModuleName() string
 	getVndkExtendsModuleName() string
 	isPgoCompile() bool
+	isPgoInstrumented() bool
 	isNDKStubLibrary() bool
 	useClangLld(actx ModuleContext) bool
 	apexName() string
@@ -386,12 +395,19 @@ type Module struct {
 	vndkdep   *vndkdep
 	lto       *lto
 	pgo       *pgo
+	afdo      *afdo
 	xom       *xom
 
 	androidMkSharedLibDeps []string
 
 	outputFile android.OptionalPath
 
+	// The symbol file generated from the unstripped output, see symbols.go.
+	symbolsFile android.Path
+
+	// The shared libraries packaged with a host test in a test suite, see test.go.
+	testSuiteSharedLibs android.Paths
+
 	cachedToolchain config.Toolchain
 
 	subAndroidMkOnce map[subAndroidMkProvider]bool
@@ -458,6 +474,9 @@ func (c *Module) Init() android.Module {
 	if c.pgo != nil {
 		c.AddProperties(c.pgo.props()...)
 	}
+	if c.afdo != nil {
+		c.AddProperties(c.afdo.props()...)
+	}
 	if c.xom != nil {
 		c.AddProperties(c.xom.props()...)
 	}
@@ -537,6 +556,13 @@ func (c *Module) isPgoCompile() bool {
 	return false
 }
 
+func (c *Module) isPgoInstrumented() bool {
+	if pgo := c.pgo; pgo != nil {
+		return pgo.Properties.ShouldProfileModule
+	}
+	return false
+}
+
 func (c *Module) isNDKStubLibrary() bool {
 	if _, ok := c.compiler.(*stubDecorator); ok {
 		return true
@@ -583,6 +609,14 @@ func (c *Module) onlyInRecovery() bool {
 	return c.ModuleBase.InstallInRecovery()
 }
 
+func (c *Module) inRamdisk() bool {
+	return c.Properties.InRamdisk || c.ModuleBase.InstallInRamdisk()
+}
+
+func (c *Module) onlyInRamdisk() bool {
+	return c.ModuleBase.InstallInRamdisk()
+}
+
 func (c *Module) IsStubs() bool {
 	if library, ok := c.linker.(*libraryDecorator); ok {
 		return library.buildStubs()
@@ -653,7 +687,7 @@ func (ctx *moduleContextImpl) staticBinary() bool {
 }
 
 func (ctx *moduleContextImpl) useSdk() bool {
-	if ctx.ctx.Device() && !ctx.useVndk() && !ctx.inRecovery() && !ctx.ctx.Fuchsia() {
+	if ctx.ctx.Device() && !ctx.useVndk() && !ctx.inRecovery() && !ctx.inRamdisk() && !ctx.ctx.Fuchsia() {
 		return String(ctx.mod.Properties.Sdk_version) != ""
 	}
 	return false
@@ -705,6 +739,10 @@ func (ctx *moduleContextImpl) isPgoCompile() bool {
 	return ctx.mod.isPgoCompile()
 }
 
+func (ctx *moduleContextImpl) isPgoInstrumented() bool {
+	return ctx.mod.isPgoInstrumented()
+}
+
 func (ctx *moduleContextImpl) isNDKStubLibrary() bool {
 	return ctx.mod.isNDKStubLibrary()
 }
@@ -725,6 +763,10 @@ func (ctx *moduleContextImpl) inRecovery() bool {
 	return ctx.mod.inRecovery()
 }
 
+func (ctx *moduleContextImpl) inRamdisk() bool {
+	return ctx.mod.inRamdisk()
+}
+
 // Check whether ABI dumps should be created for this module.
 func (ctx *moduleContextImpl) shouldCreateVndkSourceAbiDump() bool {
 	if ctx.ctx.Config().IsEnvTrue("SKIP_ABI_CHECKS") {
@@ -820,6 +862,7 @@ func newModule(hod android.HostOrDeviceSupported, multilib android.Multilib) *Mo
 	module.vndkdep = &vndkdep{}
 	module.lto = &lto{}
 	module.pgo = &pgo{}
+	module.afdo = &afdo{}
 	module.xom = &xom{}
 	return module
 }
@@ -946,6 +989,9 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	if c.pgo != nil {
 		flags = c.pgo.flags(ctx, flags)
 	}
+	if c.afdo != nil {
+		flags = c.afdo.flags(ctx, flags)
+	}
 	if c.xom != nil {
 		flags = c.xom.flags(ctx, flags)
 	}
@@ -990,6 +1036,10 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		}
 		c.outputFile = android.OptionalPathForPath(outputFile)
 
+		if _, ok := c.linker.(testSuiteProvider); ok && ctx.Host() {
+			c.testSuiteSharedLibs = testSuiteSharedLibs(ctx)
+		}
+
 		// If a lib is directly included in any of the APEXes, unhide the stubs
 		// variant having the latest version gets visible to make. In addition,
 		// the non-stubs variant is renamed to <libname>.bootstrap. This is to
@@ -998,7 +1048,7 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		// module is marked with 'bootstrap: true').
 		if c.HasStubsVariants() &&
 			android.DirectlyInAnyApex(ctx, ctx.baseModuleName()) &&
-			!c.inRecovery() && !c.useVndk() && !c.static() && !c.isCoverageVariant() &&
+			!c.inRecovery() && !c.inRamdisk() && !c.useVndk() && !c.static() && !c.isCoverageVariant() &&
 			c.IsStubs() {
 			c.Properties.HideFromMake = false // unhide
 			// Note: this is still non-installable
@@ -1010,6 +1060,12 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		if ctx.Failed() {
 			return
 		}
+
+		if i, ok := c.installer.(interface {
+			installedPath() android.OutputPath
+		}); ok {
+			c.symbolsFile = nativeSymbolsFile(ctx, c.UnstrippedOutputFile(), i.installedPath())
+		}
 	}
 }
 
@@ -1048,6 +1104,9 @@ func (c *Module) begin(ctx BaseModuleContext) {
 	if c.pgo != nil {
 		c.pgo.begin(ctx)
 	}
+	if c.afdo != nil {
+		c.afdo.begin(ctx)
+	}
 	for _, feature := range c.features {
 		feature.begin(ctx)
 	}
@@ -1285,7 +1344,7 @@ func (c *Module) DepsMutator(actx android.BottomUpMutatorContext) {
 	addSharedLibDependencies := func(depTag dependencyTag, name string, version string) {
 		var variations []blueprint.Variation
 		variations = append(variations, blueprint.Variation{Mutator: "link", Variation: "shared"})
-		versionVariantAvail := !ctx.useVndk() && !c.inRecovery()
+		versionVariantAvail := !ctx.useVndk() && !c.inRecovery() && !c.inRamdisk()
 		if version != "" && versionVariantAvail {
 			// Version is explicitly specified. i.e. libFoo#30
 			variations = append(variations, blueprint.Variation{Mutator: "version", Variation: version})
@@ -1414,8 +1473,8 @@ func checkLinkType(ctx android.ModuleContext, from *Module, to *Module, tag depe
 		// Platform code can link to anything
 		return
 	}
-	if from.inRecovery() {
-		// Recovery code is not NDK
+	if from.inRecovery() || from.inRamdisk() {
+		// Recovery and ramdisk code is not NDK
 		return
 	}
 	if _, ok := to.linker.(*toolchainLibraryDecorator); ok {
@@ -1659,8 +1718,8 @@ func (c *Module) depsToPaths(ctx android.ModuleContext) PathDeps {
 					// If not building for APEX, use stubs only when it is from
 					// an APEX (and not from platform)
 					useThisDep = (depInPlatform != depIsStubs)
-					if c.inRecovery() || c.bootstrap() {
-						// However, for recovery or bootstrap modules,
+					if c.inRecovery() || c.inRamdisk() || c.bootstrap() {
+						// However, for recovery, ramdisk or bootstrap modules,
 						// always link to non-stub variant
 						useThisDep = !depIsStubs
 					}
@@ -1802,6 +1861,8 @@ func (c *Module) depsToPaths(ctx android.ModuleContext) PathDeps {
 				return libName + vendorPublicLibrarySuffix
 			} else if ccDep.inRecovery() && !ccDep.onlyInRecovery() {
 				return libName + recoverySuffix
+			} else if ccDep.inRamdisk() && !ccDep.onlyInRamdisk() {
+				return libName + ramdiskSuffix
 			} else {
 				return libName
 			}
@@ -1879,6 +1940,10 @@ func (c *Module) InstallInRecovery() bool {
 	return c.inRecovery()
 }
 
+func (c *Module) InstallInRamdisk() bool {
+	return c.inRamdisk()
+}
+
 func (c *Module) HostToolPath() android.OptionalPath {
 	if c.installer == nil {
 		return android.OptionalPath{}
@@ -1897,6 +1962,20 @@ func (c *Module) Srcs() android.Paths {
 	return android.Paths{}
 }
 
+func (c *Module) OutputFiles(tag string) (android.Paths, error) {
+	switch tag {
+	case "":
+		return c.Srcs(), nil
+	case ".unstripped":
+		if unstripped := c.UnstrippedOutputFile(); unstripped != nil {
+			return android.Paths{unstripped}, nil
+		}
+		return android.Paths{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+	}
+}
+
 func (c *Module) static() bool {
 	if static, ok := c.linker.(interface {
 		static() bool
@@ -1928,6 +2007,8 @@ func (c *Module) getMakeLinkType() string {
 		}
 	} else if c.inRecovery() {
 		return "native:recovery"
+	} else if c.inRamdisk() {
+		return "native:ramdisk"
 	} else if c.Target().Os == android.Android && String(c.Properties.Sdk_version) != "" {
 		return "native:ndk:none:none"
 		// TODO(b/114741097): use the correct ndk stl once build errors have been fixed
@@ -1957,6 +2038,8 @@ func (c *Module) imageVariation() string {
 		variation = "vendor"
 	} else if c.inRecovery() {
 		variation = "recovery"
+	} else if c.inRamdisk() {
+		variation = "ramdisk"
 	}
 	return variation
 }
@@ -2010,6 +2093,7 @@ func DefaultsFactory(props ...interface{}) android.Module {
 		&VndkProperties{},
 		&LTOProperties{},
 		&PgoProperties{},
+		&AfdoProperties{},
 		&XomProperties{},
 		&android.ProtoProperties{},
 	)
@@ -2030,6 +2114,8 @@ const (
 	vendorMode = "vendor"
 
 	recoveryMode = "recovery"
+
+	ramdiskMode = "ramdisk"
 )
 
 func squashVendorSrcs(m *Module) {
@@ -2052,6 +2138,16 @@ func squashRecoverySrcs(m *Module) {
 	}
 }
 
+func squashRamdiskSrcs(m *Module) {
+	if lib, ok := m.compiler.(*libraryDecorator); ok {
+		lib.baseCompiler.Properties.Srcs = append(lib.baseCompiler.Properties.Srcs,
+			lib.baseCompiler.Properties.Target.Ramdisk.Srcs...)
+
+		lib.baseCompiler.Properties.Exclude_srcs = append(lib.baseCompiler.Properties.Exclude_srcs,
+			lib.baseCompiler.Properties.Target.Ramdisk.Exclude_srcs...)
+	}
+}
+
 func ImageMutator(mctx android.BottomUpMutatorContext) {
 	if mctx.Os() != android.Android {
 		return
@@ -2062,6 +2158,7 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 			var coreVariantNeeded bool = false
 			var vendorVariantNeeded bool = false
 			var recoveryVariantNeeded bool = false
+			var ramdiskVariantNeeded bool = false
 			if mctx.DeviceConfig().VndkVersion() == "" {
 				coreVariantNeeded = true
 			} else if Bool(props.Vendor_available) {
@@ -2075,12 +2172,16 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 			if Bool(props.Recovery_available) {
 				recoveryVariantNeeded = true
 			}
+			if Bool(props.Ramdisk_available) {
+				ramdiskVariantNeeded = true
+			}
 
-			if recoveryVariantNeeded {
+			if recoveryVariantNeeded || ramdiskVariantNeeded {
 				primaryArch := mctx.Config().DevicePrimaryArchType()
 				moduleArch := g.Target().Arch.ArchType
 				if moduleArch != primaryArch {
 					recoveryVariantNeeded = false
+					ramdiskVariantNeeded = false
 				}
 			}
 
@@ -2094,11 +2195,17 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 			if recoveryVariantNeeded {
 				variants = append(variants, recoveryMode)
 			}
+			if ramdiskVariantNeeded {
+				variants = append(variants, ramdiskMode)
+			}
 			mod := mctx.CreateVariations(variants...)
 			for i, v := range variants {
 				if v == recoveryMode {
 					m := mod[i].(*genrule.Module)
 					m.Extra.(*GenruleExtraProperties).InRecovery = true
+				} else if v == ramdiskMode {
+					m := mod[i].(*genrule.Module)
+					m.Extra.(*GenruleExtraProperties).InRamdisk = true
 				}
 			}
 		}
@@ -2163,6 +2270,7 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 	var coreVariantNeeded bool = false
 	var vendorVariantNeeded bool = false
 	var recoveryVariantNeeded bool = false
+	var ramdiskVariantNeeded bool = false
 
 	if mctx.DeviceConfig().VndkVersion() == "" {
 		// If the device isn't compiling against the VNDK, we always
@@ -2203,11 +2311,21 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 		coreVariantNeeded = false
 	}
 
-	if recoveryVariantNeeded {
+	if Bool(m.Properties.Ramdisk_available) {
+		ramdiskVariantNeeded = true
+	}
+
+	if m.ModuleBase.InstallInRamdisk() {
+		ramdiskVariantNeeded = true
+		coreVariantNeeded = false
+	}
+
+	if recoveryVariantNeeded || ramdiskVariantNeeded {
 		primaryArch := mctx.Config().DevicePrimaryArchType()
 		moduleArch := m.Target().Arch.ArchType
 		if moduleArch != primaryArch {
 			recoveryVariantNeeded = false
+			ramdiskVariantNeeded = false
 		}
 	}
 
@@ -2221,6 +2339,9 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 	if recoveryVariantNeeded {
 		variants = append(variants, recoveryMode)
 	}
+	if ramdiskVariantNeeded {
+		variants = append(variants, ramdiskMode)
+	}
 	mod := mctx.CreateVariations(variants...)
 	for i, v := range variants {
 		if v == vendorMode {
@@ -2232,6 +2353,11 @@ func ImageMutator(mctx android.BottomUpMutatorContext) {
 			m.Properties.InRecovery = true
 			m.MakeAsPlatform()
 			squashRecoverySrcs(m)
+		} else if v == ramdiskMode {
+			m := mod[i].(*Module)
+			m.Properties.InRamdisk = true
+			m.MakeAsPlatform()
+			squashRamdiskSrcs(m)
 		}
 	}
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -57,6 +58,7 @@ func createTestContext(t *testing.T, config android.Config, bp string, fs map[st
 	ctx := android.NewTestArchContext()
 	ctx.RegisterModuleType("cc_binary", android.ModuleFactoryAdaptor(BinaryFactory))
 	ctx.RegisterModuleType("cc_binary_host", android.ModuleFactoryAdaptor(binaryHostFactory))
+	ctx.RegisterModuleType("cc_benchmark", android.ModuleFactoryAdaptor(BenchmarkFactory))
 	ctx.RegisterModuleType("cc_library", android.ModuleFactoryAdaptor(LibraryFactory))
 	ctx.RegisterModuleType("cc_library_shared", android.ModuleFactoryAdaptor(LibrarySharedFactory))
 	ctx.RegisterModuleType("cc_library_static", android.ModuleFactoryAdaptor(LibraryStaticFactory))
@@ -66,6 +68,7 @@ func createTestContext(t *testing.T, config android.Config, bp string, fs map[st
 	ctx.RegisterModuleType("llndk_headers", android.ModuleFactoryAdaptor(llndkHeadersFactory))
 	ctx.RegisterModuleType("vendor_public_library", android.ModuleFactoryAdaptor(vendorPublicLibraryFactory))
 	ctx.RegisterModuleType("cc_object", android.ModuleFactoryAdaptor(ObjectFactory))
+	ctx.RegisterModuleType("cc_test_host", android.ModuleFactoryAdaptor(TestHostFactory))
 	ctx.RegisterModuleType("filegroup", android.ModuleFactoryAdaptor(android.FileGroupFactory))
 	ctx.PreDepsMutators(func(ctx android.RegisterMutatorsContext) {
 		ctx.BottomUp("image", ImageMutator).Parallel()
@@ -77,6 +80,7 @@ func createTestContext(t *testing.T, config android.Config, bp string, fs map[st
 	ctx.PostDepsMutators(func(ctx android.RegisterMutatorsContext) {
 		ctx.TopDown("double_loadable", checkDoubleLoadableLibraries).Parallel()
 	})
+	ctx.RegisterSingletonType("native_symbols", android.SingletonFactoryAdaptor(nativeSymbolsSingletonFactory))
 
 	// add some modules that are required by the compiler and/or linker
 	bp = bp + GatherRequiredDepsForTest(os)
@@ -87,9 +91,13 @@ func createTestContext(t *testing.T, config android.Config, bp string, fs map[st
 		"bar.c":       nil,
 		"a.proto":     nil,
 		"b.aidl":      nil,
+		"b.y":         nil,
+		"b.l":         nil,
 		"my_include":  nil,
 		"foo.map.txt": nil,
 		"liba.so":     nil,
+		"foo.rc":      nil,
+		"foo.xml":     nil,
 	}
 
 	for k, v := range fs {
@@ -2037,6 +2045,67 @@ func TestRecovery(t *testing.T) {
 	}
 }
 
+func TestRamdisk(t *testing.T) {
+	ctx := testCc(t, `
+		cc_library_shared {
+			name: "libramdisk",
+			ramdisk: true,
+		}
+		cc_library_shared {
+			name: "libbar",
+			recovery_available: true,
+			ramdisk_available: true,
+		}
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			shared_libs: ["libbar"],
+			recovery_available: true,
+			ramdisk_available: true,
+			target: {
+				recovery: {
+					cflags: ["-DRECOVERY"],
+				},
+				ramdisk: {
+					cflags: ["-DRAMDISK"],
+					exclude_shared_libs: ["libbar"],
+				},
+			},
+		}
+	`)
+
+	const ramdiskVariant = "android_arm64_armv8-a_ramdisk_shared"
+
+	variants := ctx.ModuleVariantsForTests("libramdisk")
+	if len(variants) != 1 || !android.InList(ramdiskVariant, variants) {
+		t.Errorf("variants of libramdisk must be \"%s\" only, but was %#v", ramdiskVariant, variants)
+	}
+	ctx.ModuleForTests("libramdisk", ramdiskVariant).Output("target/product/test_device/ramdisk/lib64/libramdisk.so")
+
+	recoveryCflags := ctx.ModuleForTests("libfoo", recoveryVariant).Rule("cc").Args["cFlags"]
+	if !strings.Contains(recoveryCflags, "-DRECOVERY") || strings.Contains(recoveryCflags, "-DRAMDISK") {
+		t.Errorf("cflags for the recovery variant of libfoo must contain only the recovery cflags, but was %#v", recoveryCflags)
+	}
+
+	ramdiskCflags := ctx.ModuleForTests("libfoo", ramdiskVariant).Rule("cc").Args["cFlags"]
+	for _, w := range []string{"-DRAMDISK", "-D__ANDROID_RAMDISK__"} {
+		if !strings.Contains(ramdiskCflags, w) {
+			t.Errorf("cflags for the ramdisk variant of libfoo must contain %#v, but was %#v", w, ramdiskCflags)
+		}
+	}
+	if strings.Contains(ramdiskCflags, "-DRECOVERY") {
+		t.Errorf("cflags for the ramdisk variant of libfoo must not contain the recovery cflags, but was %#v", ramdiskCflags)
+	}
+
+	libbar := getOutputPaths(ctx, recoveryVariant, []string{"libbar"})[0].String()
+	if libFlags := ctx.ModuleForTests("libfoo", recoveryVariant).Rule("ld").Args["libFlags"]; !strings.Contains(libFlags, libbar) {
+		t.Errorf("libflags for the recovery variant of libfoo must contain %#v, but was %#v", libbar, libFlags)
+	}
+	if libFlags := ctx.ModuleForTests("libfoo", ramdiskVariant).Rule("ld").Args["libFlags"]; strings.Contains(libFlags, "libbar") {
+		t.Errorf("libflags for the ramdisk variant of libfoo must not contain libbar, but was %#v", libFlags)
+	}
+}
+
 func TestVersionedStubs(t *testing.T) {
 	ctx := testCc(t, `
 		cc_library_shared {
@@ -2126,6 +2195,47 @@ func TestStaticExecutable(t *testing.T) {
 	}
 }
 
+func TestBenchmark(t *testing.T) {
+	ctx := testCc(t, `
+		cc_benchmark {
+			name: "foo_benchmark",
+			srcs: ["foo.c"],
+		}
+
+		cc_library_static {
+			name: "libgoogle-benchmark",
+			srcs: ["bar.c"],
+		}`)
+
+	testCases := []struct {
+		variant string
+		install string
+	}{
+		{"android_arm64_armv8-a_core", "data/benchmarktest64/foo_benchmark/foo_benchmark"},
+		{"android_arm_armv7-a-neon_core", "data/benchmarktest/foo_benchmark/foo_benchmark"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.variant, func(t *testing.T) {
+			module := ctx.ModuleForTests("foo_benchmark", test.variant)
+
+			libFlags := module.Rule("ld").Args["libFlags"]
+			if !strings.Contains(libFlags, "libgoogle-benchmark.a") {
+				t.Errorf("libgoogle-benchmark.a was not found in %q", libFlags)
+			}
+
+			benchmark := module.Module().(*Module).installer.(*benchmarkDecorator)
+			if g, w := benchmark.baseInstaller.path.String(), test.install; !strings.HasSuffix(g, w) {
+				t.Errorf("expected install path to end with %q, got %q", w, g)
+			}
+
+			if benchmark.testConfig == nil || benchmark.testConfig.Base() != "foo_benchmark.config" {
+				t.Errorf("expected autogenerated foo_benchmark.config, got %v", benchmark.testConfig)
+			}
+		})
+	}
+}
+
 func TestStaticDepsOrderWithStubs(t *testing.T) {
 	ctx := testCc(t, `
 		cc_binary {
@@ -2165,3 +2275,332 @@ func TestStaticDepsOrderWithStubs(t *testing.T) {
 		)
 	}
 }
+
+func TestStrip(t *testing.T) {
+	bp := `
+		cc_binary {
+			name: "default_strip",
+			srcs: ["foo.c"],
+		}
+
+		cc_binary {
+			name: "strip_all",
+			srcs: ["foo.c"],
+			strip: {
+				all: true,
+			},
+		}
+
+		cc_binary {
+			name: "strip_keep_symbols",
+			srcs: ["foo.c"],
+			strip: {
+				keep_symbols: true,
+			},
+		}`
+
+	variant := "android_arm64_armv8-a_core"
+
+	testCases := []struct {
+		name              string
+		miniDebugInfo     *bool
+		module            string
+		wantMiniDebugInfo bool
+		wantKeepSymbols   bool
+	}{
+		{"default", nil, "default_strip", true, false},
+		{"default disabled", BoolPtr(false), "default_strip", false, false},
+		{"all", nil, "strip_all", false, false},
+		{"keep_symbols", nil, "strip_keep_symbols", false, true},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := android.TestArchConfig(buildDir, nil)
+			config.TestProductVariables.Strip_mini_debug_info = test.miniDebugInfo
+			ctx := testCcWithConfig(t, bp, config)
+
+			args := ctx.ModuleForTests(test.module, variant).Rule("strip").Args["args"]
+			if g, w := strings.Contains(args, "--keep-mini-debug-info"), test.wantMiniDebugInfo; g != w {
+				t.Errorf("expected --keep-mini-debug-info %v, got %v in %q", w, g, args)
+			}
+			if g, w := strings.Contains(args, "--keep-symbols"), test.wantKeepSymbols; g != w {
+				t.Errorf("expected --keep-symbols %v, got %v in %q", w, g, args)
+			}
+		})
+	}
+}
+
+func TestStripConflictingModes(t *testing.T) {
+	testCcError(t, `only one of none, all, keep_symbols and keep_symbols_list may be set`, `
+		cc_binary {
+			name: "strip_conflict",
+			srcs: ["foo.c"],
+			strip: {
+				all: true,
+				keep_symbols: true,
+			},
+		}`)
+}
+
+func TestNativeSymbols(t *testing.T) {
+	bp := `
+		cc_binary {
+			name: "foo",
+			srcs: ["foo.c"],
+		}
+
+		cc_library_shared {
+			name: "libbar",
+			srcs: ["bar.c"],
+		}`
+
+	testCases := []struct {
+		kind    string
+		module  string
+		variant string
+		output  string
+	}{
+		{"breakpad", "foo", "android_arm64_armv8-a_core", "target/product/test_device/symbols/system/bin/foo.sym"},
+		{"breakpad", "libbar", "android_arm_armv7-a-neon_core_shared", "target/product/test_device/symbols/system/lib/libbar.so.sym"},
+		{"split_debug", "libbar", "android_arm64_armv8-a_core_shared", "target/product/test_device/symbols/system/lib64/libbar.so.debug"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.kind+"_"+test.module, func(t *testing.T) {
+			config := android.TestArchConfig(buildDir, nil)
+			config.TestProductVariables.NativeSymbols = StringPtr(test.kind)
+			ctx := testCcWithConfig(t, bp, config)
+
+			module := ctx.ModuleForTests(test.module, test.variant)
+			symbols := module.Output(test.output)
+			unstripped := module.Module().(*Module).UnstrippedOutputFile()
+			if g, w := symbols.Input.String(), unstripped.String(); g != w {
+				t.Errorf("expected symbols input %q, got %q", w, g)
+			}
+		})
+	}
+
+	ctx := testCc(t, bp)
+	if symbols := ctx.ModuleForTests("foo", "android_arm64_armv8-a_core").MaybeOutput(
+		"target/product/test_device/symbols/system/bin/foo.sym"); symbols.Rule != nil {
+		t.Errorf("expected no symbols without NativeSymbols")
+	}
+
+	config := android.TestArchConfig(buildDir, nil)
+	config.TestProductVariables.NativeSymbols = StringPtr("bogus")
+	ctx = createTestContext(t, config, bp, nil, android.Android)
+	ctx.Register()
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	android.FailIfErrored(t, errs)
+	_, errs = ctx.PrepareBuildActions(config)
+	if len(errs) != 1 {
+		t.Fatalf("expected a single error for an unknown NativeSymbols value, got %q", errs)
+	}
+	android.FailIfNoMatchingErrors(t, `unknown NativeSymbols product variable value "bogus"`, errs)
+}
+
+func TestInitRcAndVintfFragments(t *testing.T) {
+	ctx := testCc(t, `
+		cc_binary {
+			name: "foo",
+			srcs: ["foo.c"],
+			init_rc: ["foo.rc"],
+			vintf_fragments: ["foo.xml"],
+			compile_multilib: "both",
+		}`)
+
+	primary := ctx.ModuleForTests("foo", "android_arm64_armv8-a_core")
+
+	validate := primary.Rule("validateVintfFragment")
+	if g, w := validate.Input.String(), "foo.xml"; g != w {
+		t.Errorf("expected vintf fragment validation input %q, got %q", w, g)
+	}
+
+	primary.Output("target/product/test_device/system/etc/init/foo.rc")
+	vintf := primary.Output("target/product/test_device/system/etc/vintf/manifest/foo.xml")
+	if g, w := vintf.Input.String(), validate.Output.String(); g != w {
+		t.Errorf("expected validated vintf fragment %q to be installed, got %q", w, g)
+	}
+
+	secondary := ctx.ModuleForTests("foo", "android_arm_armv7-a-neon_core")
+	if rc := secondary.MaybeOutput("target/product/test_device/system/etc/init/foo.rc"); rc.Rule != nil {
+		t.Errorf("expected init_rc to be installed only by the primary variant")
+	}
+}
+
+func TestHostUnitTest(t *testing.T) {
+	ctx := testCc(t, `
+		cc_test_host {
+			name: "foo",
+			srcs: ["foo.c"],
+			gtest: false,
+			test_options: {
+				unit_test: true,
+			},
+		}
+
+		cc_test_host {
+			name: "bar",
+			srcs: ["foo.c"],
+			gtest: false,
+		}`)
+
+	variant := android.BuildOs.String() + "_x86_64"
+	foo := ctx.ModuleForTests("foo", variant)
+
+	run := foo.Rule("host_unit_test")
+	if g, w := run.Output.String(), filepath.Join(buildDir, "test_results/foo", variant, "foo.xml"); g != w {
+		t.Errorf("expected results %q, got %q", w, g)
+	}
+	if g, w := run.RuleParams.Command, "nativetest64/foo/foo"; !strings.Contains(g, w) {
+		t.Errorf("expected the installed test %q to be run, got %q", w, g)
+	}
+	if g := run.RuleParams.Command; strings.Contains(g, "-gtest") {
+		t.Errorf("expected a test that doesn't use gtest to be run without -gtest, got %q", g)
+	}
+
+	if g, w := foo.Module().(android.HostUnitTestModule).HostUnitTestResults().String(), run.Output.String(); g != w {
+		t.Errorf("expected HostUnitTestResults() %q, got %q", w, g)
+	}
+
+	if run := ctx.ModuleForTests("bar", variant).MaybeRule("host_unit_test"); run.Rule != nil {
+		t.Errorf("expected a test without test_options.unit_test not to be run")
+	}
+}
+
+func TestProfileGuidedOptimization(t *testing.T) {
+	bp := `
+		toolchain_library {
+			name: "libclang_rt.profile-aarch64-android",
+			src: "",
+		}
+		toolchain_library {
+			name: "libclang_rt.profile-arm-android",
+			src: "",
+		}
+		cc_library_shared {
+			name: "libpgo",
+			srcs: ["foo.c"],
+			pgo: {
+				instrumentation: true,
+				profile_file: "libpgo.profdata",
+				benchmarks: ["bench"],
+			},
+		}
+		cc_library_shared {
+			name: "libafdo",
+			srcs: ["foo.c"],
+			afdo: true,
+		}
+		cc_library_shared {
+			name: "libboth",
+			srcs: ["foo.c"],
+			afdo: true,
+			pgo: {
+				instrumentation: true,
+				profile_file: "libboth.profdata",
+				benchmarks: ["bench"],
+			},
+		}
+	`
+
+	const (
+		pgoGenerate = "-fprofile-generate=/data/local/tmp"
+		pgoUse      = "-fprofile-use=toolchain/pgo-profiles/libpgo.profdata"
+		afdoUse     = "-fprofile-sample-use=toolchain/pgo-profiles/sampling/libafdo.afdo"
+		bothAfdoUse = "-fprofile-sample-use=toolchain/pgo-profiles/sampling/libboth.afdo"
+	)
+
+	testCases := []struct {
+		name    string
+		config  func(config android.Config)
+		module  string
+		present []string
+		absent  []string
+	}{
+		{
+			name:    "pgo profile use",
+			module:  "libpgo",
+			present: []string{pgoUse},
+			absent:  []string{pgoGenerate},
+		},
+		{
+			name:    "afdo profile use",
+			module:  "libafdo",
+			present: []string{afdoUse},
+		},
+		{
+			name:    "afdo without pgo profile",
+			module:  "libboth",
+			present: []string{bothAfdoUse},
+			absent:  []string{pgoGenerate},
+		},
+		{
+			name: "pgo instrument",
+			config: func(config android.Config) {
+				config.TestProductVariables.PgoInstrument = []string{"bench"}
+			},
+			module:  "libpgo",
+			present: []string{pgoGenerate},
+			absent:  []string{pgoUse},
+		},
+		{
+			name: "pgo instrument disables afdo",
+			config: func(config android.Config) {
+				config.TestProductVariables.PgoInstrument = []string{"bench"}
+			},
+			module:  "libboth",
+			present: []string{pgoGenerate},
+			absent:  []string{bothAfdoUse},
+		},
+		{
+			name: "pgo no profile use",
+			config: func(config android.Config) {
+				config.TestProductVariables.PgoNoProfileUse = BoolPtr(true)
+			},
+			module: "libpgo",
+			absent: []string{pgoUse, pgoGenerate},
+		},
+		{
+			name: "afdo no profile use",
+			config: func(config android.Config) {
+				config.TestProductVariables.AfdoNoProfileUse = BoolPtr(true)
+			},
+			module: "libafdo",
+			absent: []string{afdoUse},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := android.TestArchConfig(buildDir, nil)
+			if test.config != nil {
+				test.config(config)
+			}
+
+			ctx := createTestContext(t, config, bp, map[string][]byte{
+				"toolchain/pgo-profiles/libpgo.profdata":       nil,
+				"toolchain/pgo-profiles/sampling/libafdo.afdo": nil,
+				"toolchain/pgo-profiles/sampling/libboth.afdo": nil,
+			}, android.Android)
+			ctx.Register()
+			_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+			android.FailIfErrored(t, errs)
+			_, errs = ctx.PrepareBuildActions(config)
+			android.FailIfErrored(t, errs)
+
+			cflags := ctx.ModuleForTests(test.module, coreVariant).Rule("cc").Args["cFlags"]
+			for _, flag := range test.present {
+				if !strings.Contains(cflags, flag) {
+					t.Errorf("expected %q in cflags %q", flag, cflags)
+				}
+			}
+			for _, flag := range test.absent {
+				if strings.Contains(cflags, flag) {
+					t.Errorf("unexpected %q in cflags %q", flag, cflags)
+				}
+			}
+		})
+	}
+}
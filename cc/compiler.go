@@ -60,6 +60,9 @@ type BaseCompilerProperties struct {
 	// list of module-specific flags that will be used for .y and .yy compiles
 	Yaccflags []string
 
+	// list of module-specific flags that will be used for .l and .ll compiles
+	Lexflags []string
+
 	// the instruction set architecture to use to compile the C/C++
 	// module.
 	Instruction_set *string `android:"arch_variant"`
@@ -159,6 +162,19 @@ type BaseCompilerProperties struct {
 			// variant of the C/C++ module.
 			Cflags []string
 		}
+		Ramdisk struct {
+			// list of source files that should only be used in the
+			// ramdisk variant of the C/C++ module.
+			Srcs []string `android:"path"`
+
+			// list of source files that should not be used to
+			// build the ramdisk variant of the C/C++ module.
+			Exclude_srcs []string `android:"path"`
+
+			// List of additional cflags that should be used to build the ramdisk
+			// variant of the C/C++ module.
+			Cflags []string
+		}
 	}
 
 	Proto struct {
@@ -267,6 +283,7 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 	CheckBadCompilerFlags(ctx, "asflags", compiler.Properties.Asflags)
 	CheckBadCompilerFlags(ctx, "vendor.cflags", compiler.Properties.Target.Vendor.Cflags)
 	CheckBadCompilerFlags(ctx, "recovery.cflags", compiler.Properties.Target.Recovery.Cflags)
+	CheckBadCompilerFlags(ctx, "ramdisk.cflags", compiler.Properties.Target.Ramdisk.Cflags)
 
 	esc := proptools.NinjaAndShellEscapeList
 
@@ -276,6 +293,7 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 	flags.AsFlags = append(flags.AsFlags, esc(compiler.Properties.Asflags)...)
 	flags.YasmFlags = append(flags.YasmFlags, esc(compiler.Properties.Asflags)...)
 	flags.YaccFlags = append(flags.YaccFlags, esc(compiler.Properties.Yaccflags)...)
+	flags.LexFlags = append(flags.LexFlags, esc(compiler.Properties.Lexflags)...)
 
 	// Include dir cflags
 	localIncludeDirs := android.PathsForModuleSrc(ctx, compiler.Properties.Local_include_dirs)
@@ -340,6 +358,10 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 		flags.GlobalFlags = append(flags.GlobalFlags, "-D__ANDROID_RECOVERY__")
 	}
 
+	if ctx.inRamdisk() {
+		flags.GlobalFlags = append(flags.GlobalFlags, "-D__ANDROID_RAMDISK__")
+	}
+
 	if ctx.apexName() != "" {
 		flags.GlobalFlags = append(flags.GlobalFlags, "-D__ANDROID_APEX__="+ctx.apexName())
 	}
@@ -362,7 +384,8 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 	CheckBadCompilerFlags(ctx, "clang_asflags", compiler.Properties.Clang_asflags)
 
 	flags.CFlags = config.ClangFilterUnknownCflags(flags.CFlags)
-	flags.CFlags = append(flags.CFlags, esc(compiler.Properties.Clang_cflags)...)
+	flags.CFlags = append(flags.CFlags,
+		esc(config.ClangFilterUnsupportedVersionedCflags(ctx.Config(), compiler.Properties.Clang_cflags))...)
 	flags.AsFlags = append(flags.AsFlags, esc(compiler.Properties.Clang_asflags)...)
 	flags.CppFlags = config.ClangFilterUnknownCflags(flags.CppFlags)
 	flags.ConlyFlags = config.ClangFilterUnknownCflags(flags.ConlyFlags)
@@ -442,6 +465,10 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 		flags.CFlags = append(flags.CFlags, esc(compiler.Properties.Target.Recovery.Cflags)...)
 	}
 
+	if ctx.inRamdisk() {
+		flags.CFlags = append(flags.CFlags, esc(compiler.Properties.Target.Ramdisk.Cflags)...)
+	}
+
 	// We can enforce some rules more strictly in the code we own. strict
 	// indicates if this is code that we can be stricter with. If we have
 	// rules that we want to apply to *our* code (but maybe can't for
@@ -490,7 +517,7 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 			"-I"+android.PathForModuleGen(ctx, "aidl").String())
 	}
 
-	if compiler.hasSrcExt(".rs") || compiler.hasSrcExt(".fs") {
+	if compiler.hasSrcExt(".rs") || compiler.hasSrcExt(".fs") || compiler.hasSrcExt(".rscript") {
 		flags = rsFlags(ctx, flags, &compiler.Properties)
 	}
 
@@ -17,6 +17,8 @@ package config
 import (
 	"sort"
 	"strings"
+
+	"android/soong/android"
 )
 
 // Cflags that should be filtered out when compiling with clang
@@ -202,6 +204,54 @@ func ClangFilterUnknownCflags(cflags []string) []string {
 	return ret
 }
 
+// Module-specific clang_cflags that are only understood by newer clang releases, mapped to the
+// first release that supports them.  They are dropped when compiling with an older clang, so that
+// modules can disable warnings introduced by a newer toolchain without breaking products that
+// still select an older one.
+var clangVersionedCflags = map[string]string{
+	"-Wno-misleading-indentation":    "10.0.0",
+	"-Wno-non-c-typedef-for-linkage": "11.0.0",
+	"-Wno-range-loop-construct":      "10.0.0",
+	"-Wno-reorder-init-list":         "10.0.0",
+	"-Wno-sizeof-array-div":          "10.0.0",
+	"-Wno-string-concatenation":      "12.0.0",
+}
+
+// ClangUnsupportedVersionedCflags returns the sorted list of flags from clangVersionedCflags that
+// are not supported by the clang release selected for config.
+func ClangUnsupportedVersionedCflags(config android.Config) []string {
+	shortVersion := ClangShortVersionForConfig(config)
+
+	var ret []string
+	for flag, minVersion := range clangVersionedCflags {
+		// An invalid release version is reported by checkClangVersion
+		if older, err := clangVersionOlder(shortVersion, minVersion); err == nil && older {
+			ret = append(ret, flag)
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+// ClangFilterUnsupportedVersionedCflags removes the flags that are not supported by the clang
+// release selected for config.
+func ClangFilterUnsupportedVersionedCflags(config android.Config, cflags []string) []string {
+	unsupported := ClangUnsupportedVersionedCflags(config)
+	if len(unsupported) == 0 {
+		return cflags
+	}
+
+	ret := make([]string, 0, len(cflags))
+	for _, f := range cflags {
+		if !inListSorted(f, unsupported) {
+			ret = append(ret, f)
+		}
+	}
+
+	return ret
+}
+
 func ClangFilterUnknownLldflags(lldflags []string) []string {
 	ret := make([]string, 0, len(lldflags))
 	for _, f := range lldflags {
@@ -0,0 +1,147 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestClangVersionOlder(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+		err      bool
+	}{
+		{a: "9.0.3", b: "9.0.0", expected: false},
+		{a: "9.0.0", b: "9.0.0", expected: false},
+		{a: "8.0.7", b: "9.0.0", expected: true},
+		{a: "9", b: "9.0.1", expected: true},
+		{a: "10.0.1", b: "9.0.3", expected: false},
+		{a: "9.0.3a", b: "9.0.0", err: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.a+"<"+testCase.b, func(t *testing.T) {
+			older, err := clangVersionOlder(testCase.a, testCase.b)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if older != testCase.expected {
+				t.Errorf("expected %v, got %v", testCase.expected, older)
+			}
+		})
+	}
+}
+
+func TestClangVersionForConfig(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		env                  map[string]string
+		version              string
+		shortVersion         string
+		expectedVersion      string
+		expectedShortVersion string
+		expectedUnsupported  []string
+		err                  bool
+	}{
+		{
+			name:                 "default",
+			expectedVersion:      ClangDefaultVersion,
+			expectedShortVersion: ClangDefaultShortVersion,
+			expectedUnsupported: []string{
+				"-Wno-misleading-indentation",
+				"-Wno-non-c-typedef-for-linkage",
+				"-Wno-range-loop-construct",
+				"-Wno-reorder-init-list",
+				"-Wno-sizeof-array-div",
+				"-Wno-string-concatenation",
+			},
+		},
+		{
+			name:                 "product",
+			version:              "clang-r383902",
+			shortVersion:         "11.0.1",
+			expectedVersion:      "clang-r383902",
+			expectedShortVersion: "11.0.1",
+			expectedUnsupported:  []string{"-Wno-string-concatenation"},
+		},
+		{
+			name: "env",
+			env: map[string]string{
+				"LLVM_PREBUILTS_VERSION": "clang-r399163",
+				"LLVM_RELEASE_VERSION":   "12.0.0",
+			},
+			version:              "clang-r383902",
+			shortVersion:         "11.0.1",
+			expectedVersion:      "clang-r399163",
+			expectedShortVersion: "12.0.0",
+		},
+		{
+			name:    "missing short version",
+			version: "clang-r383902",
+			err:     true,
+		},
+		{
+			name:         "too old",
+			version:      "clang-r349610",
+			shortVersion: "8.0.8",
+			err:          true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			config := android.TestConfig("out", testCase.env)
+			if testCase.version != "" {
+				version := testCase.version
+				config.TestProductVariables.ClangVersion = &version
+			}
+			if testCase.shortVersion != "" {
+				shortVersion := testCase.shortVersion
+				config.TestProductVariables.ClangShortVersion = &shortVersion
+			}
+
+			err := checkClangVersion(config)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+
+			if g, w := ClangVersionForConfig(config), testCase.expectedVersion; g != w {
+				t.Errorf("expected version %q, got %q", w, g)
+			}
+			if g, w := ClangShortVersionForConfig(config), testCase.expectedShortVersion; g != w {
+				t.Errorf("expected short version %q, got %q", w, g)
+			}
+			if g, w := ClangUnsupportedVersionedCflags(config), testCase.expectedUnsupported; !reflect.DeepEqual(g, w) {
+				t.Errorf("expected unsupported cflags %q, got %q", w, g)
+			}
+		})
+	}
+}
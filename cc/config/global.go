@@ -15,6 +15,8 @@
 package config
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"android/soong/android"
@@ -125,6 +127,11 @@ var (
 	ClangDefaultVersion      = "clang-r353983c"
 	ClangDefaultShortVersion = "9.0.3"
 
+	// The oldest clang release that the global flags are known to work with.  Products may select
+	// a different clang prebuilt with the ClangVersion and ClangShortVersion product variables,
+	// but not one older than this.
+	ClangMinimumShortVersion = "9.0.0"
+
 	// Directories with warnings from Android.bp files.
 	WarningAllowedProjects = []string{
 		"device/",
@@ -195,20 +202,17 @@ func init() {
 		return "${ClangDefaultBase}"
 	})
 	pctx.VariableFunc("ClangVersion", func(ctx android.PackageVarContext) string {
-		if override := ctx.Config().Getenv("LLVM_PREBUILTS_VERSION"); override != "" {
-			return override
+		if err := checkClangVersion(ctx.Config()); err != nil {
+			ctx.Errorf("%s", err.Error())
 		}
-		return ClangDefaultVersion
+		return ClangVersionForConfig(ctx.Config())
 	})
 	pctx.StaticVariable("ClangPath", "${ClangBase}/${HostPrebuiltTag}/${ClangVersion}")
 	pctx.StaticVariable("ClangBin", "${ClangPath}/bin")
 	pctx.StaticVariable("ClangTidyShellPath", "build/soong/scripts/clang-tidy.sh")
 
 	pctx.VariableFunc("ClangShortVersion", func(ctx android.PackageVarContext) string {
-		if override := ctx.Config().Getenv("LLVM_RELEASE_VERSION"); override != "" {
-			return override
-		}
-		return ClangDefaultShortVersion
+		return ClangShortVersionForConfig(ctx.Config())
 	})
 	pctx.StaticVariable("ClangAsanLibDir", "${ClangBase}/linux-x86/${ClangVersion}/lib64/clang/${ClangShortVersion}/lib/linux")
 
@@ -226,6 +230,8 @@ func init() {
 			"frameworks/rs/script_api/include",
 		})
 
+	// CcWrapper is prepended to C/C++ compile commands, but not to link commands.  soong_ui
+	// sets CC_WRAPPER to the compiler cache when USE_CCACHE or USE_SCCACHE is set.
 	pctx.VariableFunc("CcWrapper", func(ctx android.PackageVarContext) string {
 		if override := ctx.Config().Getenv("CC_WRAPPER"); override != "" {
 			return override + " "
@@ -236,6 +242,87 @@ func init() {
 
 var HostPrebuiltTag = pctx.VariableConfigMethod("HostPrebuiltTag", android.Config.PrebuiltOS)
 
+// ClangVersionForConfig returns the clang prebuilt version to use, from the LLVM_PREBUILTS_VERSION
+// environment variable, the ClangVersion product variable or ClangDefaultVersion, in that order.
+func ClangVersionForConfig(config android.Config) string {
+	if override := config.Getenv("LLVM_PREBUILTS_VERSION"); override != "" {
+		return override
+	}
+	if version := config.ClangVersion(); version != "" {
+		return version
+	}
+	return ClangDefaultVersion
+}
+
+// ClangShortVersionForConfig returns the release version of the clang prebuilt to use, from the
+// LLVM_RELEASE_VERSION environment variable, the ClangShortVersion product variable or
+// ClangDefaultShortVersion, in that order.
+func ClangShortVersionForConfig(config android.Config) string {
+	if override := config.Getenv("LLVM_RELEASE_VERSION"); override != "" {
+		return override
+	}
+	if version := config.ClangShortVersion(); version != "" {
+		return version
+	}
+	return ClangDefaultShortVersion
+}
+
+func checkClangVersion(config android.Config) error {
+	if config.ClangVersion() != "" && config.ClangShortVersion() == "" {
+		return fmt.Errorf("ClangVersion %q is set without setting ClangShortVersion", config.ClangVersion())
+	}
+
+	shortVersion := ClangShortVersionForConfig(config)
+	older, err := clangVersionOlder(shortVersion, ClangMinimumShortVersion)
+	if err != nil {
+		return err
+	}
+	if older {
+		return fmt.Errorf("clang release version %q is older than the minimum supported version %q",
+			shortVersion, ClangMinimumShortVersion)
+	}
+	return nil
+}
+
+// clangVersionOlder returns true if the clang release version a, for example "9.0.3", is older
+// than b.  Missing trailing components are treated as 0.
+func clangVersionOlder(a, b string) (bool, error) {
+	parse := func(version string) ([]int, error) {
+		var ret []int
+		for _, c := range strings.Split(version, ".") {
+			i, err := strconv.Atoi(c)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("invalid clang release version %q", version)
+			}
+			ret = append(ret, i)
+		}
+		return ret, nil
+	}
+
+	av, err := parse(a)
+	if err != nil {
+		return false, err
+	}
+	bv, err := parse(b)
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i < len(av) || i < len(bv); i++ {
+		var x, y int
+		if i < len(av) {
+			x = av[i]
+		}
+		if i < len(bv) {
+			y = bv[i]
+		}
+		if x != y {
+			return x < y, nil
+		}
+	}
+	return false, nil
+}
+
 func bionicHeaders(kernelArch string) string {
 	return strings.Join([]string{
 		"-isystem bionic/libc/include",
@@ -41,9 +41,10 @@ var (
 
 	lex = pctx.AndroidStaticRule("lex",
 		blueprint.RuleParams{
-			Command:     "$lexCmd -o$out $in",
+			Command:     "$lexCmd $lexFlags -o$out $in",
 			CommandDeps: []string{"$lexCmd"},
-		})
+		},
+		"lexFlags")
 
 	aidl = pctx.AndroidStaticRule("aidl",
 		blueprint.RuleParams{
@@ -104,12 +105,15 @@ func genAidl(ctx android.ModuleContext, aidlFile android.Path, outFile android.M
 	return android.Paths{outFile}
 }
 
-func genLex(ctx android.ModuleContext, lexFile android.Path, outFile android.ModuleGenPath) {
+func genLex(ctx android.ModuleContext, lexFile android.Path, outFile android.ModuleGenPath, lexFlags string) {
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        lex,
 		Description: "lex " + lexFile.Rel(),
 		Output:      outFile,
 		Input:       lexFile,
+		Args: map[string]string{
+			"lexFlags": lexFlags,
+		},
 	})
 }
 
@@ -175,11 +179,11 @@ func genSources(ctx android.ModuleContext, srcFiles android.Paths,
 		case ".l":
 			cFile := android.GenPathWithExt(ctx, "lex", srcFile, "c")
 			srcFiles[i] = cFile
-			genLex(ctx, srcFile, cFile)
+			genLex(ctx, srcFile, cFile, buildFlags.lexFlags)
 		case ".ll":
 			cppFile := android.GenPathWithExt(ctx, "lex", srcFile, "cpp")
 			srcFiles[i] = cppFile
-			genLex(ctx, srcFile, cppFile)
+			genLex(ctx, srcFile, cppFile, buildFlags.lexFlags)
 		case ".proto":
 			ccFile, headerFile := genProto(ctx, srcFile, buildFlags)
 			srcFiles[i] = ccFile
@@ -188,7 +192,7 @@ func genSources(ctx android.ModuleContext, srcFiles android.Paths,
 			cppFile := android.GenPathWithExt(ctx, "aidl", srcFile, "cpp")
 			srcFiles[i] = cppFile
 			deps = append(deps, genAidl(ctx, srcFile, cppFile, buildFlags.aidlFlags)...)
-		case ".rs", ".fs":
+		case ".rs", ".fs", ".rscript":
 			cppFile := rsGeneratedCppFile(ctx, srcFile)
 			rsFiles = append(rsFiles, srcFiles[i])
 			srcFiles[i] = cppFile
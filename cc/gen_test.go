@@ -15,7 +15,10 @@
 package cc
 
 import (
+	"path/filepath"
 	"testing"
+
+	"android/soong/android"
 )
 
 func TestGen(t *testing.T) {
@@ -60,4 +63,83 @@ func TestGen(t *testing.T) {
 		}
 	})
 
+	t.Run("yacc and lex", func(t *testing.T) {
+		ctx := testCc(t, `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: [
+				"b.y",
+				"b.l",
+			],
+			yaccflags: ["-v"],
+			lexflags: ["--nounput"],
+			yacc: {
+				export_yacc_headers: true,
+			},
+		}
+
+		cc_library_shared {
+			name: "libbar",
+			srcs: ["foo.c"],
+			shared_libs: ["libfoo"],
+		}`)
+
+		variant := "android_arm_armv7-a-neon_core_shared"
+
+		yacc := ctx.ModuleForTests("libfoo", variant).Rule("yacc")
+		if g, w := yacc.Args["yaccFlags"], "-v"; g != w {
+			t.Errorf("expected yacc flags %q, got %q", w, g)
+		}
+
+		lex := ctx.ModuleForTests("libfoo", variant).Rule("lex")
+		if g, w := lex.Args["lexFlags"], "--nounput"; g != w {
+			t.Errorf("expected lex flags %q, got %q", w, g)
+		}
+
+		yaccInclude := "-I" + filepath.Dir(yacc.ImplicitOutput.String())
+		libbar := ctx.ModuleForTests("libbar", variant).Module().(*Module)
+		if !inList(yaccInclude, libbar.flags.GlobalFlags) {
+			t.Errorf("missing exported yacc include %q in %q", yaccInclude, libbar.flags.GlobalFlags)
+		}
+	})
+
+	t.Run("yacc in subdirectory", func(t *testing.T) {
+		config := android.TestArchConfig(buildDir, nil)
+		ctx := createTestContext(t, config, `
+		cc_library_shared {
+			name: "libbar",
+			srcs: ["foo.c"],
+			shared_libs: ["libfoo"],
+		}`, map[string][]byte{
+			"foo/Android.bp": []byte(`
+			cc_library_shared {
+				name: "libfoo",
+				srcs: ["parser.y"],
+				yacc: {
+					export_yacc_headers: true,
+				},
+			}`),
+			"foo/parser.y": nil,
+		}, android.Android)
+		ctx.Register()
+
+		_, errs := ctx.ParseFileList(".", []string{"Android.bp", "foo/Android.bp"})
+		android.FailIfErrored(t, errs)
+		_, errs = ctx.PrepareBuildActions(config)
+		android.FailIfErrored(t, errs)
+
+		variant := "android_arm_armv7-a-neon_core_shared"
+
+		yaccDir := filepath.Join(buildDir, ".intermediates/foo/libfoo", variant, "gen/yacc/foo")
+		yacc := ctx.ModuleForTests("libfoo", variant).Rule("yacc")
+		if g, w := yacc.ImplicitOutput.String(), filepath.Join(yaccDir, "parser.h"); g != w {
+			t.Errorf("expected yacc header %q, got %q", w, g)
+		}
+
+		yaccInclude := "-I" + yaccDir
+		libbar := ctx.ModuleForTests("libbar", variant).Module().(*Module)
+		if !inList(yaccInclude, libbar.flags.GlobalFlags) {
+			t.Errorf("missing exported yacc include %q in %q", yaccInclude, libbar.flags.GlobalFlags)
+		}
+	})
 }
@@ -26,9 +26,13 @@ func init() {
 type GenruleExtraProperties struct {
 	Vendor_available   *bool
 	Recovery_available *bool
+	Ramdisk_available  *bool
 
 	// This genrule is for recovery variant
 	InRecovery bool `blueprint:"mutated"`
+
+	// This genrule is for ramdisk variant
+	InRamdisk bool `blueprint:"mutated"`
 }
 
 // cc_genrule is a genrule that can depend on other cc_* objects.
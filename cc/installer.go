@@ -80,6 +80,10 @@ func (installer *baseInstaller) install(ctx ModuleContext, file android.Path) {
 	installer.path = ctx.InstallFile(installer.installDir(ctx), file.Base(), file)
 }
 
+func (installer *baseInstaller) installedPath() android.OutputPath {
+	return installer.path
+}
+
 func (installer *baseInstaller) inData() bool {
 	return installer.location == InstallInData
 }
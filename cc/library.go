@@ -67,6 +67,16 @@ type LibraryProperties struct {
 		Export_proto_headers *bool
 	}
 
+	Yacc struct {
+		// export headers generated from .y and .yy sources
+		Export_yacc_headers *bool
+	}
+
+	Renderscript struct {
+		// export headers generated from .rs, .fs and .rscript sources
+		Export_renderscript_headers *bool
+	}
+
 	Sysprop struct {
 		// Whether platform owns this sysprop library.
 		Platform *bool
@@ -518,6 +528,7 @@ func (library *libraryDecorator) linkerInit(ctx BaseModuleContext) {
 	}
 	library.baseInstaller.location = location
 	library.baseLinker.linkerInit(ctx)
+	library.stripper.checkStripProperties(ctx)
 	// Let baseLinker know whether this variant is for stubs or not, so that
 	// it can omit things that are not required for linking stubs.
 	library.baseLinker.dynamicProperties.BuildStubs = library.buildStubs()
@@ -601,6 +612,13 @@ func (library *libraryDecorator) linkerDeps(ctx DepsContext, deps Deps) Deps {
 		deps.ReexportSharedLibHeaders = removeListFromList(deps.ReexportSharedLibHeaders, library.baseLinker.Properties.Target.Recovery.Exclude_shared_libs)
 		deps.ReexportStaticLibHeaders = removeListFromList(deps.ReexportStaticLibHeaders, library.baseLinker.Properties.Target.Recovery.Exclude_static_libs)
 	}
+	if ctx.inRamdisk() {
+		deps.WholeStaticLibs = removeListFromList(deps.WholeStaticLibs, library.baseLinker.Properties.Target.Ramdisk.Exclude_static_libs)
+		deps.SharedLibs = removeListFromList(deps.SharedLibs, library.baseLinker.Properties.Target.Ramdisk.Exclude_shared_libs)
+		deps.StaticLibs = removeListFromList(deps.StaticLibs, library.baseLinker.Properties.Target.Ramdisk.Exclude_static_libs)
+		deps.ReexportSharedLibHeaders = removeListFromList(deps.ReexportSharedLibHeaders, library.baseLinker.Properties.Target.Ramdisk.Exclude_shared_libs)
+		deps.ReexportStaticLibHeaders = removeListFromList(deps.ReexportStaticLibHeaders, library.baseLinker.Properties.Target.Ramdisk.Exclude_static_libs)
+	}
 
 	return deps
 }
@@ -849,6 +867,31 @@ func (library *libraryDecorator) link(ctx ModuleContext,
 		}
 	}
 
+	if Bool(library.Properties.Yacc.Export_yacc_headers) {
+		if library.baseCompiler.hasSrcExt(".y") || library.baseCompiler.hasSrcExt(".yy") {
+			flags := []string{
+				"-I" + android.PathForModuleGen(ctx, "yacc", ctx.ModuleDir()).String(),
+			}
+			library.reexportFlags(flags)
+			library.reuseExportedFlags = append(library.reuseExportedFlags, flags...)
+			library.reexportDeps(library.baseCompiler.pathDeps) // TODO: restrict to yacc deps
+			library.reuseExportedDeps = append(library.reuseExportedDeps, library.baseCompiler.pathDeps...)
+		}
+	}
+
+	if Bool(library.Properties.Renderscript.Export_renderscript_headers) {
+		if library.baseCompiler.hasSrcExt(".rs") || library.baseCompiler.hasSrcExt(".fs") ||
+			library.baseCompiler.hasSrcExt(".rscript") {
+			flags := []string{
+				"-I" + android.PathForModuleGen(ctx, "rs").String(),
+			}
+			library.reexportFlags(flags)
+			library.reuseExportedFlags = append(library.reuseExportedFlags, flags...)
+			library.reexportDeps(library.baseCompiler.pathDeps) // TODO: restrict to renderscript deps
+			library.reuseExportedDeps = append(library.reuseExportedDeps, library.baseCompiler.pathDeps...)
+		}
+	}
+
 	if library.baseCompiler.hasSrcExt(".sysprop") {
 		internalFlags := []string{
 			"-I" + android.PathForModuleGen(ctx, "sysprop", "include").String(),
@@ -938,7 +981,7 @@ func (library *libraryDecorator) install(ctx ModuleContext, file android.Path) {
 			// Bionic libraries (e.g. libc.so) is installed to the bootstrap subdirectory.
 			// The original path becomes a symlink to the corresponding file in the
 			// runtime APEX.
-			if isBionic(ctx.baseModuleName()) && !library.buildStubs() && ctx.Arch().Native && !ctx.inRecovery() {
+			if isBionic(ctx.baseModuleName()) && !library.buildStubs() && ctx.Arch().Native && !ctx.inRecovery() && !ctx.inRamdisk() {
 				if ctx.Device() {
 					library.installSymlinkToRuntimeApex(ctx, file)
 				}
@@ -949,7 +992,7 @@ func (library *libraryDecorator) install(ctx ModuleContext, file android.Path) {
 	}
 
 	if Bool(library.Properties.Static_ndk_lib) && library.static() &&
-		!ctx.useVndk() && !ctx.inRecovery() && ctx.Device() &&
+		!ctx.useVndk() && !ctx.inRecovery() && !ctx.inRamdisk() && ctx.Device() &&
 		library.baseLinker.sanitize.isUnsanitizedVariant() &&
 		!library.buildStubs() {
 		installPath := getNdkSysrootBase(ctx).Join(
@@ -1146,7 +1189,7 @@ func latestStubsVersionFor(config android.Config, name string) string {
 // Version mutator splits a module into the mandatory non-stubs variant
 // (which is unnamed) and zero or more stubs variants.
 func VersionMutator(mctx android.BottomUpMutatorContext) {
-	if m, ok := mctx.Module().(*Module); ok && !m.inRecovery() && m.linker != nil {
+	if m, ok := mctx.Module().(*Module); ok && !m.inRecovery() && !m.inRamdisk() && m.linker != nil {
 		if library, ok := m.linker.(*libraryDecorator); ok && library.buildShared() &&
 			len(library.Properties.Stubs.Versions) > 0 {
 			versions := []string{}
@@ -1190,7 +1233,7 @@ func VersionMutator(mctx android.BottomUpMutatorContext) {
 		return
 	}
 	if genrule, ok := mctx.Module().(*genrule.Module); ok {
-		if props, ok := genrule.Extra.(*GenruleExtraProperties); ok && !props.InRecovery {
+		if props, ok := genrule.Extra.(*GenruleExtraProperties); ok && !props.InRecovery && !props.InRamdisk {
 			mctx.CreateVariations("")
 			return
 		}
@@ -28,6 +28,8 @@ var (
 	modulesAddedWallKey          = android.NewOnceKey("ModulesAddedWall")
 	modulesUsingWnoErrorKey      = android.NewOnceKey("ModulesUsingWnoError")
 	modulesMissingProfileFileKey = android.NewOnceKey("ModulesMissingProfileFile")
+
+	modulesMissingAfdoProfileFileKey = android.NewOnceKey("ModulesMissingAfdoProfileFile")
 )
 
 func init() {
@@ -77,6 +79,9 @@ func makeVarsProvider(ctx android.MakeVarsContext) {
 	ctx.Strict("PATH_TO_CLANG_TIDY", "${config.ClangBin}/clang-tidy")
 	ctx.Strict("PATH_TO_CLANG_TIDY_SHELL", "${config.ClangTidyShellPath}")
 	ctx.StrictSorted("CLANG_CONFIG_UNKNOWN_CFLAGS", strings.Join(config.ClangUnknownCflags, " "))
+	ctx.StrictSorted("CLANG_CONFIG_UNSUPPORTED_VERSIONED_CFLAGS",
+		strings.Join(config.ClangUnsupportedVersionedCflags(ctx.Config()), " "))
+	ctx.Strict("LLVM_MIN_RELEASE_VERSION", config.ClangMinimumShortVersion)
 
 	ctx.Strict("RS_LLVM_PREBUILTS_VERSION", "${config.RSClangVersion}")
 	ctx.Strict("RS_LLVM_PREBUILTS_BASE", "${config.RSClangBase}")
@@ -121,6 +126,7 @@ func makeVarsProvider(ctx android.MakeVarsContext) {
 	ctx.Strict("SOONG_MODULES_ADDED_WALL", makeStringOfKeys(ctx, modulesAddedWallKey))
 	ctx.Strict("SOONG_MODULES_USING_WNO_ERROR", makeStringOfKeys(ctx, modulesUsingWnoErrorKey))
 	ctx.Strict("SOONG_MODULES_MISSING_PGO_PROFILE_FILE", makeStringOfKeys(ctx, modulesMissingProfileFileKey))
+	ctx.Strict("SOONG_MODULES_MISSING_AFDO_PROFILE_FILE", makeStringOfKeys(ctx, modulesMissingAfdoProfileFileKey))
 
 	ctx.Strict("ADDRESS_SANITIZER_CONFIG_EXTRA_CFLAGS", strings.Join(asanCflags, " "))
 	ctx.Strict("ADDRESS_SANITIZER_CONFIG_EXTRA_LDFLAGS", strings.Join(asanLdflags, " "))
@@ -236,14 +236,14 @@ func (pgo *pgo) begin(ctx BaseModuleContext) {
 		return
 	}
 
-	// This module should be instrumented if ANDROID_PGO_INSTRUMENT is set
-	// and includes 'all', 'ALL' or a benchmark listed for this module.
+	// This module should be instrumented if ANDROID_PGO_INSTRUMENT or the
+	// product's PGO_INSTRUMENT list includes 'all', 'ALL' or a benchmark
+	// listed for this module.
 	//
 	// TODO Validate that each benchmark instruments at least one module
 	pgo.Properties.ShouldProfileModule = false
-	pgoBenchmarks := ctx.Config().Getenv("ANDROID_PGO_INSTRUMENT")
 	pgoBenchmarksMap := make(map[string]bool)
-	for _, b := range strings.Split(pgoBenchmarks, ",") {
+	for _, b := range ctx.DeviceConfig().PgoInstrumentBenchmarks() {
 		pgoBenchmarksMap[b] = true
 	}
 
@@ -258,7 +258,7 @@ func (pgo *pgo) begin(ctx BaseModuleContext) {
 		}
 	}
 
-	if !ctx.Config().IsEnvTrue("ANDROID_PGO_NO_PROFILE_USE") &&
+	if ctx.DeviceConfig().PgoProfileUseEnabled() &&
 		proptools.BoolDefault(pgo.Properties.Pgo.Enable_profile_use, true) {
 		if profileFile := pgo.Properties.getPgoProfileFile(ctx); profileFile.Valid() {
 			pgo.Properties.PgoCompile = true
@@ -286,7 +286,7 @@ func (pgo *pgo) flags(ctx ModuleContext, flags Flags) Flags {
 		return props.addProfileGatherFlags(ctx, flags)
 	}
 
-	if !ctx.Config().IsEnvTrue("ANDROID_PGO_NO_PROFILE_USE") {
+	if ctx.DeviceConfig().PgoProfileUseEnabled() {
 		return props.addProfileUseFlags(ctx, flags)
 	}
 
@@ -72,4 +72,34 @@ func TestProto(t *testing.T) {
 		}
 	})
 
+	t.Run("filegroup path", func(t *testing.T) {
+		bp := `
+		filegroup {
+			name: "protos",
+			srcs: ["protos/a/b.proto"],
+			path: "protos",
+		}
+
+		cc_library_shared {
+			name: "libfoo",
+			srcs: [":protos"],
+		}`
+
+		config := android.TestArchConfig(buildDir, nil)
+		ctx := createTestContext(t, config, bp, map[string][]byte{
+			"protos/a/b.proto": nil,
+		}, android.Android)
+		ctx.Register()
+		_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+		android.FailIfErrored(t, errs)
+		_, errs = ctx.PrepareBuildActions(config)
+		android.FailIfErrored(t, errs)
+
+		proto := ctx.ModuleForTests("libfoo", "android_arm_armv7-a-neon_core_shared").Output("proto/a/b.pb.cc")
+
+		if cmd, w := proto.RuleParams.Command, "-Iprotos "; !strings.Contains(cmd, w) {
+			t.Errorf("expected %q in %q", w, cmd)
+		}
+	})
+
 }
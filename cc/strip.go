@@ -21,12 +21,25 @@ import (
 )
 
 type StripProperties struct {
+	// Controls how the linked output is stripped.  By default device modules are stripped of
+	// all symbols and debug info, but keep a compressed copy of the symbol table in a
+	// .gnu_debugdata section (mini-debug-info) unless the product disables it.  At most one
+	// of none, all, keep_symbols and keep_symbols_list may be set.
 	Strip struct {
-		None              *bool    `android:"arch_variant"`
-		All               *bool    `android:"arch_variant"`
-		Keep_symbols      *bool    `android:"arch_variant"`
+		// don't strip the output at all
+		None *bool `android:"arch_variant"`
+
+		// strip all symbols and debug info, and don't embed mini-debug-info
+		All *bool `android:"arch_variant"`
+
+		// strip debug info but keep the full symbol table
+		Keep_symbols *bool `android:"arch_variant"`
+
+		// strip everything except the listed symbols
 		Keep_symbols_list []string `android:"arch_variant"`
-		Use_gnu_strip     *bool    `android:"arch_variant"`
+
+		// use GNU strip and objcopy instead of llvm-strip and llvm-objcopy
+		Use_gnu_strip *bool `android:"arch_variant"`
 	} `android:"arch_variant"`
 }
 
@@ -39,6 +52,37 @@ func (stripper *stripper) needsStrip(ctx ModuleContext) bool {
 	return (!ctx.Config().EmbeddedInMake() || ctx.Device()) && !Bool(stripper.StripProperties.Strip.None)
 }
 
+// checkStripProperties reports an error if more than one strip mode is set.  It is called once per
+// module variant from linkerInit.
+func (stripper *stripper) checkStripProperties(ctx BaseModuleContext) {
+	props := stripper.StripProperties.Strip
+	var modes []string
+	if Bool(props.None) {
+		modes = append(modes, "none")
+	}
+	if Bool(props.All) {
+		modes = append(modes, "all")
+	}
+	if Bool(props.Keep_symbols) {
+		modes = append(modes, "keep_symbols")
+	}
+	if len(props.Keep_symbols_list) > 0 {
+		modes = append(modes, "keep_symbols_list")
+	}
+	if len(modes) > 1 {
+		ctx.PropertyErrorf("strip", "only one of none, all, keep_symbols and keep_symbols_list may be set, found %s",
+			strings.Join(modes, ", "))
+	}
+}
+
+// keepMiniDebugInfo returns true if the default strip mode should embed mini-debug-info.
+func (stripper *stripper) keepMiniDebugInfo(ctx ModuleContext) bool {
+	if Bool(stripper.StripProperties.Strip.All) {
+		return false
+	}
+	return ctx.Host() || ctx.Config().MiniDebugInfoEnabled()
+}
+
 func (stripper *stripper) strip(ctx ModuleContext, in android.Path, out android.ModuleOutPath,
 	flags builderFlags) {
 	if ctx.Darwin() {
@@ -48,7 +92,7 @@ func (stripper *stripper) strip(ctx ModuleContext, in android.Path, out android.
 			flags.stripKeepSymbols = true
 		} else if len(stripper.StripProperties.Strip.Keep_symbols_list) > 0 {
 			flags.stripKeepSymbolsList = strings.Join(stripper.StripProperties.Strip.Keep_symbols_list, ",")
-		} else if !Bool(stripper.StripProperties.Strip.All) {
+		} else if stripper.keepMiniDebugInfo(ctx) {
 			flags.stripKeepMiniDebugInfo = true
 		}
 		if Bool(stripper.StripProperties.Strip.Use_gnu_strip) {
@@ -0,0 +1,134 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// When the NativeSymbols product variable is set, a symbol file is generated from the unstripped
+// output of every installed device binary and library into $(PRODUCT_OUT)/symbols, at the same
+// path relative to $(PRODUCT_OUT) as the installed file.  "breakpad" generates breakpad .sym files
+// with dump_syms, "split_debug" generates .debug files that only contain the debug sections.  All
+// of the symbol files are built by the native-symbols phony target.
+
+const (
+	nativeSymbolsBreakpad   = "breakpad"
+	nativeSymbolsSplitDebug = "split_debug"
+)
+
+func init() {
+	pctx.HostBinToolVariable("dumpSymsCmd", "dump_syms")
+
+	android.RegisterSingletonType("native_symbols", nativeSymbolsSingletonFactory)
+}
+
+var (
+	breakpadSymbols = pctx.AndroidStaticRule("breakpadSymbols",
+		blueprint.RuleParams{
+			Command:     "$dumpSymsCmd $in > $out.tmp && mv $out.tmp $out",
+			CommandDeps: []string{"$dumpSymsCmd"},
+		})
+
+	splitDebugSymbols = pctx.AndroidStaticRule("splitDebugSymbols",
+		blueprint.RuleParams{
+			Command:     "${config.ClangBin}/llvm-objcopy --only-keep-debug $in $out",
+			CommandDeps: []string{"${config.ClangBin}/llvm-objcopy"},
+		})
+)
+
+// nativeSymbolsFile generates the symbol file for a module whose unstripped output was installed
+// to installed.  It returns nil if no symbol file should be generated.
+func nativeSymbolsFile(ctx ModuleContext, unstripped android.Path, installed android.OutputPath) android.Path {
+	kind := ctx.DeviceConfig().NativeSymbols()
+	if kind == "" || ctx.Host() || unstripped == nil {
+		return nil
+	}
+
+	productOut := filepath.Join("target", "product", ctx.Config().DeviceName())
+	rel, err := filepath.Rel(productOut, installed.RelPathString())
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		// Not installed into $(PRODUCT_OUT), for example an NDK stub library.
+		return nil
+	}
+
+	var rule blueprint.Rule
+	var ext string
+	switch kind {
+	case nativeSymbolsBreakpad:
+		rule, ext = breakpadSymbols, ".sym"
+	case nativeSymbolsSplitDebug:
+		rule, ext = splitDebugSymbols, ".debug"
+	default:
+		// Reported once by the native_symbols singleton.
+		return nil
+	}
+
+	symbolsFile := android.PathForOutput(ctx, productOut, "symbols", rel+ext)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        rule,
+		Description: "symbols " + symbolsFile.Base(),
+		Output:      symbolsFile,
+		Input:       unstripped,
+	})
+
+	return symbolsFile
+}
+
+func nativeSymbolsSingletonFactory() android.Singleton {
+	return &nativeSymbolsSingleton{}
+}
+
+type nativeSymbolsSingleton struct {
+	symbolsFiles android.Paths
+}
+
+func (s *nativeSymbolsSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	switch kind := ctx.DeviceConfig().NativeSymbols(); kind {
+	case "":
+		return
+	case nativeSymbolsBreakpad, nativeSymbolsSplitDebug:
+	default:
+		ctx.Errorf("unknown NativeSymbols product variable value %q, expected %q or %q",
+			kind, nativeSymbolsBreakpad, nativeSymbolsSplitDebug)
+		return
+	}
+
+	ctx.VisitAllModules(func(module android.Module) {
+		if c, ok := module.(*Module); ok && c.Enabled() && c.symbolsFile != nil {
+			s.symbolsFiles = append(s.symbolsFiles, c.symbolsFile)
+		}
+	})
+
+	sort.Slice(s.symbolsFiles, func(i, j int) bool {
+		return s.symbolsFiles[i].String() < s.symbolsFiles[j].String()
+	})
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:      blueprint.Phony,
+		Output:    android.PathForPhony(ctx, "native-symbols"),
+		Implicits: s.symbolsFiles,
+	})
+}
+
+func (s *nativeSymbolsSingleton) MakeVars(ctx android.MakeVarsContext) {
+	ctx.Strict("SOONG_NATIVE_SYMBOLS", strings.Join(s.symbolsFiles.Strings(), " "))
+}
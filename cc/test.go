@@ -34,6 +34,10 @@ type TestProperties struct {
 type TestOptions struct {
 	// The UID that you want to run the test as on a device.
 	Run_test_as *string
+
+	// If set, the host variant of the test is a unit test that is run during the build by the
+	// host-unit-tests target.
+	Unit_test *bool
 }
 
 type TestBinaryProperties struct {
@@ -114,6 +118,76 @@ func BenchmarkHostFactory() android.Module {
 	return module.Init()
 }
 
+// testSuiteProvider is implemented by the linkers of test modules that can be packaged into
+// test suites.
+type testSuiteProvider interface {
+	testSuites() []string
+	testSuiteData() android.Paths
+	testSuiteConfig() android.Path
+}
+
+func (c *Module) TestSuites() []string {
+	if test, ok := c.linker.(testSuiteProvider); ok {
+		return test.testSuites()
+	}
+	return nil
+}
+
+func (c *Module) TestSuiteInfo() android.TestSuiteInfo {
+	if test, ok := c.linker.(testSuiteProvider); ok && c.outputFile.Valid() {
+		return android.TestSuiteInfo{
+			Output:     c.outputFile.Path(),
+			Data:       test.testSuiteData(),
+			Config:     test.testSuiteConfig(),
+			SharedLibs: c.testSuiteSharedLibs,
+		}
+	}
+	return android.TestSuiteInfo{}
+}
+
+// testSuiteSharedLibs returns the outputs of the transitive shared library dependencies of a
+// host test, which are packaged with it so that it can run from the test suite.  Device tests
+// use the libraries installed on the device instead.
+func testSuiteSharedLibs(ctx android.ModuleContext) android.Paths {
+	var libs android.Paths
+	ctx.WalkDeps(func(child, parent android.Module) bool {
+		depTag, ok := ctx.OtherModuleDependencyTag(child).(dependencyTag)
+		if !ok {
+			return false
+		}
+		depTag.explicitlyVersioned = false
+		switch depTag {
+		case sharedDepTag, sharedExportDepTag, lateSharedDepTag, earlySharedDepTag:
+		default:
+			return false
+		}
+		ccDep, ok := child.(*Module)
+		if !ok || !ccDep.outputFile.Valid() {
+			return false
+		}
+		libs = append(libs, ccDep.outputFile.Path())
+		return true
+	})
+	return android.FirstUniquePaths(libs)
+}
+
+var _ android.TestSuiteModule = (*Module)(nil)
+
+// hostUnitTestProvider is implemented by the linkers of test modules that can be run as host
+// unit tests.
+type hostUnitTestProvider interface {
+	hostUnitTestResults() android.OptionalPath
+}
+
+func (c *Module) HostUnitTestResults() android.OptionalPath {
+	if test, ok := c.linker.(hostUnitTestProvider); ok {
+		return test.hostUnitTestResults()
+	}
+	return android.OptionalPath{}
+}
+
+var _ android.HostUnitTestModule = (*Module)(nil)
+
 type testPerSrc interface {
 	testPerSrc() bool
 	srcs() []string
@@ -215,6 +289,12 @@ func (test *testDecorator) linkerDeps(ctx BaseModuleContext, deps Deps) Deps {
 }
 
 func (test *testDecorator) linkerInit(ctx BaseModuleContext, linker *baseLinker) {
+	addTestRunPaths(ctx, linker)
+}
+
+// addTestRunPaths adds the rpaths used by test and benchmark binaries that are installed into
+// their own directory under nativetest or benchmarktest, or packaged into a test suite.
+func addTestRunPaths(ctx BaseModuleContext, linker *baseLinker) {
 	// 1. Add ../../lib[64] to rpath so that out/host/linux-x86/nativetest/<test dir>/<test> can
 	// find out/host/linux-x86/lib[64]/library.so
 	// 2. Add ../../../lib[64] to rpath so that out/host/linux-x86/testcases/<test dir>/<CPU>/<test> can
@@ -246,6 +326,8 @@ type testBinary struct {
 	Properties TestBinaryProperties
 	data       android.Paths
 	testConfig android.Path
+
+	unitTestResults android.OptionalPath
 }
 
 func (test *testBinary) linkerProps() []interface{} {
@@ -296,6 +378,42 @@ func (test *testBinary) install(ctx ModuleContext, file android.Path) {
 	}
 
 	test.binaryDecorator.baseInstaller.install(ctx, file)
+
+	if Bool(test.Properties.Test_options.Unit_test) && ctx.Host() && android.CanRunHostUnitTest(ctx) {
+		test.runUnitTest(ctx)
+	}
+}
+
+// runUnitTest runs the installed test binary, so that it can find its shared libraries through
+// the test rpaths.
+func (test *testBinary) runUnitTest(ctx ModuleContext) {
+	rule := android.NewRuleBuilder()
+	cmd, results := android.HostUnitTestCommand(ctx, rule)
+	if test.gtest() {
+		cmd.Flag("-gtest")
+	}
+	cmd.Flag("--").
+		Input(test.binaryDecorator.baseInstaller.path).
+		Implicits(test.data)
+	rule.Build(pctx, ctx, "host_unit_test", "run host unit test "+ctx.ModuleName())
+
+	test.unitTestResults = android.OptionalPathForPath(results)
+}
+
+func (test *testBinary) hostUnitTestResults() android.OptionalPath {
+	return test.unitTestResults
+}
+
+func (test *testBinary) testSuites() []string {
+	return test.Properties.Test_suites
+}
+
+func (test *testBinary) testSuiteData() android.Paths {
+	return test.data
+}
+
+func (test *testBinary) testSuiteConfig() android.Path {
+	return test.testConfig
 }
 
 func NewTest(hod android.HostOrDeviceSupported) *Module {
@@ -381,11 +499,7 @@ type benchmarkDecorator struct {
 }
 
 func (benchmark *benchmarkDecorator) linkerInit(ctx BaseModuleContext) {
-	runpath := "../../lib"
-	if ctx.toolchain().Is64Bit() {
-		runpath += "64"
-	}
-	benchmark.baseLinker.dynamicProperties.RunPaths = append(benchmark.baseLinker.dynamicProperties.RunPaths, runpath)
+	addTestRunPaths(ctx, benchmark.baseLinker)
 	benchmark.binaryDecorator.linkerInit(ctx)
 }
 
@@ -411,6 +525,18 @@ func (benchmark *benchmarkDecorator) install(ctx ModuleContext, file android.Pat
 	benchmark.binaryDecorator.baseInstaller.install(ctx, file)
 }
 
+func (benchmark *benchmarkDecorator) testSuites() []string {
+	return benchmark.Properties.Test_suites
+}
+
+func (benchmark *benchmarkDecorator) testSuiteData() android.Paths {
+	return benchmark.data
+}
+
+func (benchmark *benchmarkDecorator) testSuiteConfig() android.Path {
+	return benchmark.testConfig
+}
+
 func NewBenchmark(hod android.HostOrDeviceSupported) *Module {
 	module, binary := NewBinary(hod)
 	module.multilib = android.MultilibBoth
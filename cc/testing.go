@@ -24,6 +24,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libatomic",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -31,6 +32,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libcompiler_rt-extras",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -38,6 +40,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libclang_rt.builtins-arm-android",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -45,6 +48,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libclang_rt.builtins-aarch64-android",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -52,6 +56,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libclang_rt.builtins-i686-android",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -59,6 +64,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libclang_rt.builtins-x86_64-android",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -66,6 +72,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libgcc",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -73,6 +80,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			name: "libgcc_stripped",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			src: "",
 		}
 
@@ -82,6 +90,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			nocrt: true,
 			system_shared_libs: [],
 			recovery_available: true,
+			ramdisk_available: true,
 		}
 		llndk_library {
 			name: "libc",
@@ -93,6 +102,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			nocrt: true,
 			system_shared_libs: [],
 			recovery_available: true,
+			ramdisk_available: true,
 		}
 		llndk_library {
 			name: "libm",
@@ -104,6 +114,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			nocrt: true,
 			system_shared_libs: [],
 			recovery_available: true,
+			ramdisk_available: true,
 		}
 		llndk_library {
 			name: "libdl",
@@ -117,6 +128,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			stl: "none",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 		}
 		cc_library {
 			name: "libc++",
@@ -126,6 +138,7 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			stl: "none",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 			vndk: {
 				enabled: true,
 				support_system_process: true,
@@ -139,29 +152,34 @@ func GatherRequiredDepsForTest(os android.OsType) string {
 			stl: "none",
 			vendor_available: true,
 			recovery_available: true,
+			ramdisk_available: true,
 		}
 
 		cc_object {
 			name: "crtbegin_so",
 			recovery_available: true,
+			ramdisk_available: true,
 			vendor_available: true,
 		}
 
 		cc_object {
 			name: "crtbegin_static",
 			recovery_available: true,
+			ramdisk_available: true,
 			vendor_available: true,
 		}
 
 		cc_object {
 			name: "crtend_so",
 			recovery_available: true,
+			ramdisk_available: true,
 			vendor_available: true,
 		}
 
 		cc_object {
 			name: "crtend_android",
 			recovery_available: true,
+			ramdisk_available: true,
 			vendor_available: true,
 		}
 
@@ -19,6 +19,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -387,3 +388,58 @@ func TestDepPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	existing := map[string]bool{
+		"out/a.o":    true,
+		"out/gen.h":  true,
+		"out/undecl": true,
+		"src/a.c":    true,
+	}
+	exists := func(path string) bool { return existing[path] }
+
+	testCases := []struct {
+		name     string
+		deps     *Deps
+		outputs  []string
+		problems []string
+	}{
+		{
+			name:    "ok",
+			deps:    &Deps{Output: "out/a.o", Inputs: []string{"src/a.c", "out/gen.h"}},
+			outputs: []string{"out/a.o"},
+		},
+		{
+			name:     "missing output",
+			outputs:  []string{"out/a.o", "out/b.o"},
+			problems: []string{`declared output "out/b.o" was not created`},
+		},
+		{
+			name:     "output outside out",
+			outputs:  []string{"out/a.o", "src/a.o"},
+			problems: []string{`declared output "src/a.o" is outside of "out"`},
+		},
+		{
+			name:     "target outside out",
+			deps:     &Deps{Output: "src/a.o", Inputs: []string{"src/a.c"}},
+			problems: []string{`depfile target "src/a.o" is outside of "out"`},
+		},
+		{
+			name: "bad inputs",
+			deps: &Deps{Output: "out/a.o", Inputs: []string{"src/missing.h", "out/undecl"}},
+			problems: []string{
+				`depfile input "src/missing.h" does not exist`,
+				`depfile input "out/undecl" is a generated file that is not a declared input`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			problems := Validate(tc.deps, "out", tc.outputs, []string{"out/gen.h"}, exists)
+			if !reflect.DeepEqual(problems, tc.problems) {
+				t.Errorf("want problems:\n%q\ngot:\n%q", tc.problems, problems)
+			}
+		})
+	}
+}
@@ -33,9 +33,18 @@ func main() {
 		flag.PrintDefaults()
 	}
 	output := flag.String("o", "", "Optional output file (defaults to rewriting source if necessary)")
+	validate := flag.Bool("validate", false, "Validate the declared outputs and the depfile of a rule after it ran")
+	strict := flag.Bool("strict", false, "With -validate, fail instead of printing warnings")
+	outDir := flag.String("out_dir", "out", "With -validate, the output directory of the build")
+	var outputs, declaredInputs stringList
+	flag.Var(&outputs, "output", "With -validate, a declared output of the rule (may be repeated)")
+	flag.Var(&declaredInputs, "declared_input", "With -validate, a declared generated input of the rule (may be repeated)")
 	flag.Parse()
 
 	if flag.NArg() < 1 {
+		if *validate {
+			validateAndExit(nil, *outDir, outputs, declaredInputs, *strict)
+		}
 		log.Fatal("Expected at least one input file as an argument")
 	}
 
@@ -64,7 +73,8 @@ func main() {
 	new := mergedDeps.Print()
 
 	if *output == "" || *output == flag.Arg(0) {
-		if !bytes.Equal(firstInput, new) {
+		// With -validate the depfile is only checked, not rewritten.
+		if !*validate && !bytes.Equal(firstInput, new) {
 			err := ioutil.WriteFile(flag.Arg(0), new, 0666)
 			if err != nil {
 				log.Fatalf("Failed to write: %v", err)
@@ -76,4 +86,25 @@ func main() {
 			log.Fatalf("Failed to write to %q: %v", *output, err)
 		}
 	}
+
+	if *validate {
+		validateAndExit(mergedDeps, *outDir, outputs, declaredInputs, *strict)
+	}
+}
+
+func validateAndExit(deps *Deps, outDir string, outputs, declaredInputs []string, strict bool) {
+	problems := Validate(deps, outDir, outputs, declaredInputs, fileExists)
+
+	level := "warning"
+	if strict {
+		level = "error"
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", level, problem)
+	}
+
+	if strict && len(problems) > 0 {
+		os.Exit(1)
+	}
+	os.Exit(0)
 }
@@ -0,0 +1,89 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stringList is a flag.Value that accumulates the values of a flag passed multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// inDir returns true if path is dir or is inside dir.
+func inDir(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// Validate checks the outputs and, if deps is not nil, the depfile of a rule after it ran, and
+// returns a description of each problem found:
+//   - every declared output must be inside outDir and must exist.
+//   - the depfile target must be inside outDir.
+//   - every depfile input must exist.
+//   - every depfile input inside outDir must be a declared input or output of the rule, otherwise
+//     the rule depends on a generated file that ninja won't build before it.
+func Validate(deps *Deps, outDir string, outputs, declaredInputs []string,
+	exists func(string) bool) []string {
+
+	var problems []string
+
+	for _, output := range outputs {
+		if !inDir(output, outDir) {
+			problems = append(problems, fmt.Sprintf("declared output %q is outside of %q", output, outDir))
+		} else if !exists(output) {
+			problems = append(problems, fmt.Sprintf("declared output %q was not created", output))
+		}
+	}
+
+	if deps == nil {
+		return problems
+	}
+
+	if !inDir(deps.Output, outDir) {
+		problems = append(problems, fmt.Sprintf("depfile target %q is outside of %q", deps.Output, outDir))
+	}
+
+	declared := make(map[string]bool)
+	for _, path := range append(append([]string(nil), declaredInputs...), outputs...) {
+		declared[filepath.Clean(path)] = true
+	}
+
+	for _, input := range deps.Inputs {
+		if !exists(input) {
+			problems = append(problems, fmt.Sprintf("depfile input %q does not exist", input))
+		} else if inDir(input, outDir) && !declared[filepath.Clean(input)] {
+			problems = append(problems, fmt.Sprintf("depfile input %q is a generated file that is not a declared input", input))
+		}
+	}
+
+	return problems
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
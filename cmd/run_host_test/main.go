@@ -0,0 +1,121 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This tool runs a host unit test as part of the build and writes its results in JUnit XML format.
+// gtest binaries write the results themselves with --gtest_output; JUnit tests are run with
+// JUnitCore on the test classes found in the test jar, and their result is converted to a single
+// test case.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	output   = flag.String("o", "", "Output file for the results in JUnit XML format")
+	gtest    = flag.Bool("gtest", false, "The command is a gtest binary that writes its own results")
+	junitJar = flag.String("junit_jar", "", "Jar whose test classes are appended to the command")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s -o <results.xml> [-gtest | -junit_jar <jar>] -- <command> [<args>...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *output == "" || flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *gtest && *junitJar != "" {
+		fatalf("-gtest and -junit_jar are mutually exclusive")
+	}
+
+	if err := run(*output, *gtest, *junitJar, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func run(output string, gtest bool, junitJar string, args []string) error {
+	name := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+
+	if err := os.MkdirAll(filepath.Dir(output), 0777); err != nil {
+		return err
+	}
+	// Remove stale results so that a crashing gtest binary can't leave results from a previous run.
+	if err := os.Remove(output); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if gtest {
+		abs, err := filepath.Abs(output)
+		if err != nil {
+			return err
+		}
+		args = append(args, "--gtest_output=xml:"+abs)
+	}
+
+	if junitJar != "" {
+		classes, err := testClassesFromJar(junitJar)
+		if err != nil {
+			return err
+		}
+		if len(classes) == 0 {
+			return fmt.Errorf("no test classes found in %s", junitJar)
+		}
+		args = append(args, classes...)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	runErr := cmd.Run()
+
+	if _, err := os.Stat(output); os.IsNotExist(err) {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		err = writeResults(f, name, out.String(), runErr)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if runErr != nil {
+		io.Copy(os.Stderr, &out)
+		return fmt.Errorf("%s failed: %s", name, runErr)
+	}
+	return nil
+}
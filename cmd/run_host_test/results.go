@@ -0,0 +1,107 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+type testSuites struct {
+	XMLName xml.Name    `xml:"testsuites"`
+	Suites  []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Cases    []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string       `xml:"name,attr"`
+	Failure   *testFailure `xml:"failure,omitempty"`
+	SystemOut string       `xml:"system-out,omitempty"`
+}
+
+type testFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeResults writes a JUnit XML results file containing a single test case for a test
+// that doesn't write its own results.  runErr is the error returned by running the test.
+func writeResults(w io.Writer, name, output string, runErr error) error {
+	c := testCase{
+		Name:      name,
+		SystemOut: output,
+	}
+	suite := testSuite{
+		Name:  name,
+		Tests: 1,
+	}
+	if runErr != nil {
+		c.Failure = &testFailure{
+			Message: runErr.Error(),
+			Text:    output,
+		}
+		c.SystemOut = ""
+		suite.Failures = 1
+	}
+	suite.Cases = []testCase{c}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(testSuites{Suites: []testSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// testClassesFromJar returns the sorted names of the top level classes in a jar whose names end
+// in Test or Tests.
+func testClassesFromJar(jar string) ([]string, error) {
+	r, err := zip.OpenReader(jar)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return testClasses(&r.Reader), nil
+}
+
+func testClasses(r *zip.Reader) []string {
+	var classes []string
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".class") || strings.Contains(f.Name, "$") {
+			continue
+		}
+		class := strings.TrimSuffix(f.Name, ".class")
+		if base := path.Base(class); strings.HasSuffix(base, "Test") || strings.HasSuffix(base, "Tests") {
+			classes = append(classes, strings.Replace(class, "/", ".", -1))
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
@@ -34,6 +34,8 @@ var (
 	keepOutDir    bool
 	copyAllOutput bool
 	depfileOut    string
+
+	undeclaredOutputs string
 )
 
 func init() {
@@ -51,6 +53,9 @@ func init() {
 	flag.StringVar(&depfileOut, "depfile-out", "",
 		"file path of the depfile to generate. This value will replace '__SBOX_DEPFILE__' in the command and will be treated as an output but won't be added to __SBOX_OUT_FILES__")
 
+	flag.StringVar(&undeclaredOutputs, "undeclared-outputs", "",
+		"what to do with files created in the sandbox that are not declared outputs: \"warn\" to print a warning, \"error\" to fail, or empty to ignore them")
+
 }
 
 func usageViolation(violation string) {
@@ -114,6 +119,9 @@ func run() error {
 	if len(outputRoot) == 0 {
 		usageViolation("--output-root <outputRoot> is required and must be non-empty")
 	}
+	if undeclaredOutputs != "" && undeclaredOutputs != "warn" && undeclaredOutputs != "error" {
+		usageViolation("--undeclared-outputs must be \"warn\" or \"error\"")
+	}
 
 	// the contents of the __SBOX_OUT_FILES__ variable
 	outputsVarEntries := flag.Args()
@@ -258,6 +266,20 @@ func run() error {
 		keepOutDir = true
 		return errors.New(errorMessage)
 	}
+	if undeclaredOutputs != "" && !copyAllOutput {
+		if undeclared := findUndeclaredOutputs(tempDir, allOutputs); len(undeclared) > 0 {
+			message := fmt.Sprintf("sbox command (%s) created %v files that are not declared outputs:\n",
+				commandDescription, len(undeclared))
+			for _, filePath := range undeclared {
+				message += "  " + filePath + "\n"
+			}
+			if undeclaredOutputs == "error" {
+				return errors.New(message)
+			}
+			fmt.Fprint(os.Stderr, "warning: "+message)
+		}
+	}
+
 	var filePathList []string
 	if copyAllOutput {
 		filePathList = findAllFilesUnder(tempDir)
@@ -290,6 +312,21 @@ func run() error {
 		}
 	}
 
-	// TODO(jeffrygaston) if a process creates more output files than it declares, should there be a warning?
 	return nil
 }
+
+// findUndeclaredOutputs returns the files created in the sandbox that are not in declaredOutputs.
+func findUndeclaredOutputs(tempDir string, declaredOutputs []string) []string {
+	declared := make(map[string]bool)
+	for _, filePath := range declaredOutputs {
+		declared[filepath.Clean(filePath)] = true
+	}
+
+	var undeclared []string
+	for _, filePath := range findAllFilesUnder(tempDir) {
+		if !declared[filepath.Clean(filePath)] {
+			undeclared = append(undeclared, filePath)
+		}
+	}
+	return undeclared
+}
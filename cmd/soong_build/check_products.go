@@ -0,0 +1,281 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"android/soong/android"
+)
+
+// The -check_products mode analyzes the module graph for a list of products without writing a
+// build manifest, so that the Android.bp files can be checked against many device configurations
+// in one invocation.  Each product is described by a build directory containing the soong.config
+// and soong.variables files produced by its product config, for example
+// out/multiproduct/<product>/soong.  -check_products doesn't run product config itself, so the
+// build directories must have been generated beforehand, for example by running multiproduct_kati
+// with --only-config.  The products are analyzed in parallel, each one in its own soong_build
+// process running in -check_product mode, since the android package keeps build state in globals
+// shared by every Context in a process.
+
+var (
+	checkProducts     string
+	checkProductsJobs int
+	checkProduct      string
+)
+
+func init() {
+	flag.StringVar(&checkProducts, "check_products", "",
+		"comma-separated list of product build directories, already generated by product config, to analyze without generating a build manifest")
+	flag.IntVar(&checkProductsJobs, "check_products_j", runtime.NumCPU(),
+		"number of products to analyze in parallel with -check_products")
+	flag.StringVar(&checkProduct, "check_product", "",
+		"product build directory to analyze without generating a build manifest")
+}
+
+// checkProductFlags returns whether -check_product and -check_products were passed on the command
+// line, even if they were set to an empty value.
+func checkProductFlags() (product, products bool) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "check_product":
+			product = true
+		case "check_products":
+			products = true
+		}
+	})
+	return product, products
+}
+
+// splitBuildDirs splits the value of -check_products, dropping empty entries left by extra commas.
+func splitBuildDirs(s string) []string {
+	var buildDirs []string
+	for _, buildDir := range strings.Split(s, ",") {
+		if buildDir = strings.TrimSpace(buildDir); buildDir != "" {
+			buildDirs = append(buildDirs, buildDir)
+		}
+	}
+	return buildDirs
+}
+
+// moduleListFile returns the file listing the Android.bp files to parse, as passed to the
+// bootstrap with -l.
+func moduleListFile() string {
+	if f := flag.Lookup("l"); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// bootstrapModuleTypes are the Go module types that are registered by bootstrap.Main, which isn't
+// run in -check_product mode.  Placeholders are registered for them so that Android.bp files using
+// them can be parsed while unknown module types are still reported.
+var bootstrapModuleTypes = map[string]android.ModuleFactory{
+	"bootstrap_go_package": bootstrapGoPackageFactory,
+	"bootstrap_go_binary":  bootstrapGoBinaryFactory,
+	"blueprint_go_binary":  bootstrapGoBinaryFactory,
+}
+
+// bootstrapGoProperties accepts the properties of the bootstrap Go module types.
+type bootstrapGoProperties struct {
+	Deps           []string
+	PkgPath        string
+	PluginFor      []string
+	Srcs           []string
+	TestSrcs       []string
+	PrimaryBuilder bool
+	Default        bool
+
+	Darwin struct {
+		Srcs     []string
+		TestSrcs []string
+	}
+	Linux struct {
+		Srcs     []string
+		TestSrcs []string
+	}
+}
+
+// bootstrapGoModule stands in for a bootstrap Go module, Go binaries provide their install path
+// as a host tool so that genrules can use them.
+type bootstrapGoModule struct {
+	android.ModuleBase
+
+	properties bootstrapGoProperties
+
+	binary   bool
+	hostTool android.OptionalPath
+}
+
+func newBootstrapGoModule(binary bool) android.Module {
+	module := &bootstrapGoModule{binary: binary}
+	module.AddProperties(&module.properties)
+	android.InitAndroidModule(module)
+	return module
+}
+
+func bootstrapGoPackageFactory() android.Module {
+	return newBootstrapGoModule(false)
+}
+
+func bootstrapGoBinaryFactory() android.Module {
+	return newBootstrapGoModule(true)
+}
+
+func (m *bootstrapGoModule) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	if m.binary {
+		m.hostTool = android.OptionalPathForPath(ctx.Config().HostToolPath(ctx, ctx.ModuleName()))
+	}
+}
+
+func (m *bootstrapGoModule) HostToolPath() android.OptionalPath {
+	return m.hostTool
+}
+
+// newCheckProductContext returns a Context to analyze a product with checkProductMain.
+func newCheckProductContext(configuration android.Config) *android.Context {
+	ctx := newContext(configuration)
+	for name, factory := range bootstrapModuleTypes {
+		ctx.RegisterModuleType(name, android.ModuleFactoryAdaptor(factory))
+	}
+	return ctx
+}
+
+// loadProduct reads the configuration of the product whose soong.config and soong.variables are
+// in buildDir, and the list of Android.bp files to parse.
+func loadProduct(srcDir, buildDir string) (android.Config, []string, error) {
+	configuration, err := android.NewConfig(srcDir, buildDir)
+	if err != nil {
+		return android.Config{}, nil, err
+	}
+
+	listFile := moduleListFile()
+	if listFile == "" {
+		return android.Config{}, nil, fmt.Errorf("-check_product requires the list of Android.bp files passed with -l")
+	}
+	list, err := ioutil.ReadFile(listFile)
+	if err != nil {
+		return android.Config{}, nil, err
+	}
+
+	return configuration, strings.Fields(string(list)), nil
+}
+
+// checkProductMain parses the Android.bp files and analyzes the module graph for a product
+// without generating a build manifest, and returns the errors found.
+func checkProductMain(ctx *android.Context, configuration android.Config, srcDir string, files []string) []error {
+	if _, errs := ctx.ParseFileList(srcDir, files); len(errs) > 0 {
+		return errs
+	}
+	if _, errs := ctx.ResolveDependencies(configuration); len(errs) > 0 {
+		return errs
+	}
+	if _, errs := ctx.PrepareBuildActions(configuration); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkProductsMain runs soong_build in -check_product mode for each of the product build
+// directories in parallel, and reports the errors of each product.  It returns false if any
+// product failed.
+func checkProductsMain(topFile string, buildDirs []string) bool {
+	type result struct {
+		output []byte
+		err    error
+	}
+	results := make([]result, len(buildDirs))
+
+	jobs := checkProductsJobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	sem := make(chan bool, jobs)
+
+	var wg sync.WaitGroup
+	for i, buildDir := range buildDirs {
+		wg.Add(1)
+		go func(i int, buildDir string) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+
+			cmd := exec.Command(os.Args[0], "-l", moduleListFile(), "-check_product", buildDir, topFile)
+			results[i].output, results[i].err = cmd.CombinedOutput()
+		}(i, buildDir)
+	}
+	wg.Wait()
+
+	ok := true
+	for i, buildDir := range buildDirs {
+		if results[i].err == nil {
+			fmt.Printf("%s: ok\n", buildDir)
+			continue
+		}
+		ok = false
+		fmt.Printf("%s: FAILED: %s\n", buildDir, results[i].err)
+		if output := bytes.TrimSpace(results[i].output); len(output) > 0 {
+			fmt.Printf("    %s\n", bytes.Replace(output, []byte("\n"), []byte("\n    "), -1))
+		}
+	}
+	return ok
+}
+
+// checkProductsCommand handles the -check_products and -check_product flags and returns the
+// exit code of soong_build.
+func checkProductsCommand(topFile string) int {
+	if product, _ := checkProductFlags(); product {
+		if strings.TrimSpace(checkProduct) == "" {
+			fmt.Fprintln(os.Stderr, "-check_product requires a product build directory")
+			return 1
+		}
+
+		srcDir := filepath.Dir(topFile)
+		configuration, files, err := loadProduct(srcDir, checkProduct)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		errs := checkProductMain(newCheckProductContext(configuration), configuration, srcDir, files)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if len(errs) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	buildDirs := splitBuildDirs(checkProducts)
+	if len(buildDirs) == 0 {
+		fmt.Fprintln(os.Stderr, "-check_products requires at least one product build directory")
+		return 1
+	}
+
+	if !checkProductsMain(topFile, buildDirs) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestCheckProductMain(t *testing.T) {
+	buildDir, err := ioutil.TempDir("", "soong_check_products_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	testCases := []struct {
+		name string
+		bp   string
+		err  string
+	}{
+		{
+			name: "pass",
+			bp: `
+				filegroup {
+					name: "fg",
+					srcs: ["a.txt"],
+				}
+
+				bootstrap_go_package {
+					name: "soong-foo",
+					pkgPath: "android/soong/foo",
+					deps: ["blueprint"],
+					srcs: ["foo.go"],
+					testSrcs: ["foo_test.go"],
+					pluginFor: ["soong_build"],
+				}
+
+				blueprint_go_binary {
+					name: "foo_tool",
+					srcs: ["main.go"],
+					linux: {
+						srcs: ["main_linux.go"],
+					},
+				}
+			`,
+		},
+		{
+			name: "unknown module type",
+			bp: `
+				filegroupp {
+					name: "fg",
+					srcs: ["a.txt"],
+				}
+			`,
+			err: `unrecognized module type "filegroupp"`,
+		},
+		{
+			name: "missing source",
+			bp: `
+				filegroup {
+					name: "fg",
+					srcs: ["missing.txt"],
+				}
+			`,
+			err: `missing.txt`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := android.TestArchConfig(buildDir, nil)
+			ctx := newCheckProductContext(config)
+			ctx.MockFileSystem(map[string][]byte{
+				"Android.bp": []byte(test.bp),
+				"a.txt":      nil,
+			})
+
+			errs := checkProductMain(ctx, config, ".", []string{"Android.bp"})
+			if test.err == "" {
+				android.FailIfErrored(t, errs)
+			} else {
+				android.FailIfNoMatchingErrors(t, test.err, errs)
+			}
+		})
+	}
+}
+
+func TestSplitBuildDirs(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want []string
+	}{
+		{"out/a", []string{"out/a"}},
+		{"out/a,out/b", []string{"out/a", "out/b"}},
+		{"out/a,", []string{"out/a"}},
+		{"out/a,, out/b ,", []string{"out/a", "out/b"}},
+		{",", nil},
+		{"", nil},
+	}
+
+	for _, test := range testCases {
+		if got := splitBuildDirs(test.in); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitBuildDirs(%q): want %q, got %q", test.in, test.want, got)
+		}
+	}
+}
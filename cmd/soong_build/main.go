@@ -49,15 +49,29 @@ func newNameResolver(config android.Config) *android.NameResolver {
 	return android.NewNameResolver(exportFilter)
 }
 
+// newContext returns a Context with all module types, mutators and singletons registered, that
+// resolves module names for the given configuration.
+func newContext(configuration android.Config) *android.Context {
+	ctx := android.NewContext()
+	ctx.Register()
+
+	ctx.SetNameInterface(newNameResolver(configuration))
+
+	ctx.SetAllowMissingDependencies(configuration.AllowMissingDependencies())
+
+	return ctx
+}
+
 func main() {
 	flag.Parse()
 
+	if product, products := checkProductFlags(); product || products {
+		os.Exit(checkProductsCommand(flag.Arg(0)))
+	}
+
 	// The top-level Blueprints file is passed as the first argument.
 	srcDir := filepath.Dir(flag.Arg(0))
 
-	ctx := android.NewContext()
-	ctx.Register()
-
 	configuration, err := android.NewConfig(srcDir, bootstrap.BuildDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s", err)
@@ -68,9 +82,7 @@ func main() {
 		configuration.SetStopBefore(bootstrap.StopBeforePrepareBuildActions)
 	}
 
-	ctx.SetNameInterface(newNameResolver(configuration))
-
-	ctx.SetAllowMissingDependencies(configuration.AllowMissingDependencies())
+	ctx := newContext(configuration)
 
 	bootstrap.Main(ctx.Context, configuration, configuration.ConfigFileName, configuration.ProductVariablesFileName)
 
@@ -12,18 +12,23 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// env implements the environment JSON file handling for the soong_env command line tool run before
-// the builder and for the env writer in the builder.
+// env implements the environment JSON file handling shared by soong_build and soong_ui.
+// soong_build writes the environment variables it read and their values to UsedEnvFile, and
+// soong_ui compares them with its own environment before running the build.  If any of them
+// changed, soong_ui removes the file, which forces soong_build to regenerate the build manifest.
 package env
 
 import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"sort"
 )
 
+// UsedEnvFile is the name of the file in the Soong output directory that lists the environment
+// variables read by soong_build and their values.
+const UsedEnvFile = "soong.environment.used"
+
 type envFileEntry struct{ Key, Value string }
 type envFileData []envFileEntry
 
@@ -50,38 +55,33 @@ func WriteEnvFile(filename string, envDeps map[string]string) error {
 	return nil
 }
 
-func StaleEnvFile(filename string) (bool, error) {
+// ChangedEnvVars returns a description of each of the environment variables recorded in filename
+// whose value returned by getenv differs from the recorded value.  Variables that were not read
+// by the builder are not recorded in the file, and changes to them are ignored.
+func ChangedEnvVars(filename string, getenv func(string) string) ([]string, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return true, err
+		return nil, err
 	}
 
 	var contents envFileData
 
 	err = json.Unmarshal(data, &contents)
 	if err != nil {
-		return true, err
+		return nil, err
 	}
 
 	var changed []string
 	for _, entry := range contents {
 		key := entry.Key
 		old := entry.Value
-		cur := os.Getenv(key)
+		cur := getenv(key)
 		if old != cur {
 			changed = append(changed, fmt.Sprintf("%s (%q -> %q)", key, old, cur))
 		}
 	}
 
-	if len(changed) > 0 {
-		fmt.Printf("environment variables changed value:\n")
-		for _, s := range changed {
-			fmt.Printf("   %s\n", s)
-		}
-		return true, nil
-	}
-
-	return false, nil
+	return changed, nil
 }
 
 func (e envFileData) Len() int {
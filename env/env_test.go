@@ -0,0 +1,94 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChangedEnvVars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soong_env_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	envFile := filepath.Join(dir, UsedEnvFile)
+	err = WriteEnvFile(envFile, map[string]string{
+		"USED":       "a",
+		"USED_EMPTY": "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		expected []string
+	}{
+		{
+			name: "unchanged",
+			env: map[string]string{
+				"USED": "a",
+			},
+		},
+		{
+			name: "unused changed",
+			env: map[string]string{
+				"USED":   "a",
+				"UNUSED": "b",
+			},
+		},
+		{
+			name: "used changed",
+			env: map[string]string{
+				"USED":       "b",
+				"USED_EMPTY": "c",
+			},
+			expected: []string{
+				`USED ("a" -> "b")`,
+				`USED_EMPTY ("" -> "c")`,
+			},
+		},
+		{
+			name: "used unset",
+			env:  map[string]string{},
+			expected: []string{
+				`USED ("a" -> "")`,
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return test.env[key]
+			}
+
+			changed, err := ChangedEnvVars(envFile, getenv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changed, test.expected) {
+				t.Errorf("expected changed %q, got %q", test.expected, changed)
+			}
+		})
+	}
+}
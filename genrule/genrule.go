@@ -139,6 +139,20 @@ func (g *Module) Srcs() android.Paths {
 	return append(android.Paths{}, g.outputFiles...)
 }
 
+// OutputFiles returns all of the generated files for the empty tag, or the single generated file
+// whose path relative to the output directory matches the tag.
+func (g *Module) OutputFiles(tag string) (android.Paths, error) {
+	if tag == "" {
+		return g.Srcs(), nil
+	}
+	for _, out := range g.outputFiles {
+		if out.Rel() == tag {
+			return android.Paths{out}, nil
+		}
+	}
+	return nil, fmt.Errorf("no output file named %q", tag)
+}
+
 func (g *Module) GeneratedHeaderDirs() android.Paths {
 	return g.exportedIncludeDirs
 }
@@ -370,8 +384,16 @@ func (g *Module) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	// Escape the command for the shell
 	rawCommand = "'" + strings.Replace(rawCommand, "'", `'\''`, -1) + "'"
 	g.rawCommand = rawCommand
-	sandboxCommand := fmt.Sprintf("$sboxCmd --sandbox-path %s --output-root %s -c %s %s $allouts",
-		sandboxPath, genDir, rawCommand, depfilePlaceholder)
+	validation := ctx.Config().RuleValidation()
+	undeclaredOutputsFlag := ""
+	switch validation {
+	case android.RuleValidationWarn:
+		undeclaredOutputsFlag = "--undeclared-outputs warn "
+	case android.RuleValidationStrict:
+		undeclaredOutputsFlag = "--undeclared-outputs error "
+	}
+	sandboxCommand := fmt.Sprintf("$sboxCmd --sandbox-path %s --output-root %s %s-c %s %s $allouts",
+		sandboxPath, genDir, undeclaredOutputsFlag, rawCommand, depfilePlaceholder)
 
 	ruleParams := blueprint.RuleParams{
 		Command:     sandboxCommand,
@@ -381,6 +403,13 @@ func (g *Module) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	if Bool(g.properties.Depfile) {
 		ruleParams.Deps = blueprint.DepsGCC
 		args = append(args, "depfileArgs")
+		if validation != "" {
+			// sbox checks the outputs, dep_fixer validates the depfile after it ran.
+			ruleParams.Command += " && $validateDepfileCmd"
+			ruleParams.CommandDeps = append(ruleParams.CommandDeps,
+				ctx.Config().HostToolPath(ctx, "dep_fixer").String())
+			args = append(args, "validateDepfileCmd")
+		}
 	}
 	g.rule = ctx.Rule(pctx, "generator", ruleParams, args...)
 
@@ -417,6 +446,10 @@ func (g *Module) generateSourceFile(ctx android.ModuleContext, task generateTask
 	if Bool(g.properties.Depfile) {
 		params.Depfile = android.PathForModuleGen(ctx, task.out[0].Rel()+".d")
 		params.Args["depfileArgs"] = "--depfile-out " + depFile.String()
+		if ctx.Config().RuleValidation() != "" {
+			inputs := append(append(android.Paths(nil), task.in...), g.deps...)
+			params.Args["validateDepfileCmd"] = android.RuleValidationCmd(ctx, depFile, task.out, inputs).String()
+		}
 	}
 
 	ctx.Build(pctx, params)
@@ -559,3 +559,38 @@ func (t *testTool) HostToolPath() android.OptionalPath {
 }
 
 var _ android.HostToolProvider = (*testTool)(nil)
+
+func TestGenruleValidation(t *testing.T) {
+	config := android.TestArchConfig(buildDir, nil)
+	config.TestProductVariables.RuleValidation = proptools.StringPtr(android.RuleValidationStrict)
+	bp := `
+		genrule {
+			name: "gen",
+			srcs: ["in1"],
+			out: ["out"],
+			depfile: true,
+			cmd: "cp $(in) $(out) && touch $(depfile)",
+		}
+	`
+	ctx := testContext(config, bp, nil)
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp"})
+	if errs == nil {
+		_, errs = ctx.PrepareBuildActions(config)
+	}
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	gen := ctx.ModuleForTests("gen", "").Rule("generator")
+	if !strings.Contains(gen.RuleParams.Command, "--undeclared-outputs error") {
+		t.Errorf("expected sbox to fail on undeclared outputs, got command %q", gen.RuleParams.Command)
+	}
+
+	validate := gen.Args["validateDepfileCmd"]
+	if !strings.Contains(validate, "dep_fixer -validate -strict") {
+		t.Errorf("expected depfile validation in strict mode, got %q", validate)
+	}
+	if !strings.HasSuffix(validate, "out.d") {
+		t.Errorf("expected depfile validation of out.d, got %q", validate)
+	}
+}
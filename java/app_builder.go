@@ -83,6 +83,12 @@ func CreateAppPackage(ctx android.ModuleContext, outputFile android.WritablePath
 		Output: unsignedApk,
 	})
 
+	SignAppPackage(ctx, outputFile, unsignedApk, certificates)
+}
+
+// SignAppPackage signs unsignedApk with the given certificates using signapk.
+func SignAppPackage(ctx android.ModuleContext, signedApk android.WritablePath, unsignedApk android.Path, certificates []Certificate) {
+
 	var certificateArgs []string
 	var deps android.Paths
 	for _, c := range certificates {
@@ -93,7 +99,7 @@ func CreateAppPackage(ctx android.ModuleContext, outputFile android.WritablePath
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        Signapk,
 		Description: "signapk",
-		Output:      outputFile,
+		Output:      signedApk,
 		Input:       unsignedApk,
 		Implicits:   deps,
 		Args: map[string]string{
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"testing"
@@ -1151,3 +1152,210 @@ func TestUncompressDex(t *testing.T) {
 		})
 	}
 }
+
+func TestAndroidAppImport(t *testing.T) {
+	ctx := testJava(t, `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			certificate: "platform",
+			dex_preopt: {
+				enabled: true,
+			},
+		}
+		`)
+
+	variant := ctx.ModuleForTests("foo", "android_common")
+
+	// Check dexpreopt outputs.
+	if variant.MaybeOutput("dexpreopt/oat/arm64/package.vdex").Rule == nil ||
+		variant.MaybeOutput("dexpreopt/oat/arm64/package.odex").Rule == nil {
+		t.Errorf("can't find dexpreopt outputs")
+	}
+
+	// Check cert signing flag.
+	signedApk := variant.Output("signed/foo.apk")
+	signingFlag := signedApk.Args["certificates"]
+	expected := "build/make/target/product/security/platform.x509.pem build/make/target/product/security/platform.pk8"
+	if expected != signingFlag {
+		t.Errorf("Incorrect signing flags, expected: %q, got: %q", expected, signingFlag)
+	}
+}
+
+func TestAndroidAppImport_NoDexPreopt(t *testing.T) {
+	ctx := testJava(t, `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			certificate: "platform",
+			dex_preopt: {
+				enabled: false,
+			},
+		}
+		`)
+
+	variant := ctx.ModuleForTests("foo", "android_common")
+
+	// Check dexpreopt outputs. They shouldn't exist.
+	if variant.MaybeOutput("dexpreopt/oat/arm64/package.vdex").Rule != nil ||
+		variant.MaybeOutput("dexpreopt/oat/arm64/package.odex").Rule != nil {
+		t.Errorf("dexpreopt shouldn't have run.")
+	}
+}
+
+func TestAndroidAppImport_Presigned(t *testing.T) {
+	ctx := testJava(t, `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			presigned: true,
+			dex_preopt: {
+				enabled: true,
+			},
+		}
+		`)
+
+	variant := ctx.ModuleForTests("foo", "android_common")
+
+	// Check dexpreopt outputs.
+	if variant.MaybeOutput("dexpreopt/oat/arm64/package.vdex").Rule == nil ||
+		variant.MaybeOutput("dexpreopt/oat/arm64/package.odex").Rule == nil {
+		t.Errorf("can't find dexpreopt outputs")
+	}
+
+	// Presigned apks must not be signed again, nor have their dex files stripped.
+	if variant.MaybeRule("signapk").Rule != nil {
+		t.Errorf("presigned apk shouldn't be signed")
+	}
+	if variant.MaybeRule("dexpreopt_strip").Rule != nil {
+		t.Errorf("presigned apk shouldn't be stripped")
+	}
+	if variant.MaybeOutput("zip-aligned/foo.apk").Rule == nil {
+		t.Errorf("can't find aligned apk")
+	}
+}
+
+func TestAndroidAppImport_CertificateErrors(t *testing.T) {
+	testJavaError(t, "No certificate specified for prebuilt", `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+		}
+		`)
+
+	testJavaError(t, "Certificate can't be specified for presigned modules", `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			certificate: "platform",
+			presigned: true,
+		}
+		`)
+}
+
+func TestAndroidAppImport_DpiVariants(t *testing.T) {
+	bp := `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			dpi_variants: {
+				xhdpi: {
+					apk: "prebuilts/apk/app_xhdpi.apk",
+				},
+				xxhdpi: {
+					apk: "prebuilts/apk/app_xxhdpi.apk",
+				},
+			},
+			presigned: true,
+			dex_preopt: {
+				enabled: true,
+			},
+		}
+		`
+	testCases := []struct {
+		name                string
+		aaptPreferredConfig *string
+		aaptPrebuiltDPI     []string
+		expected            string
+	}{
+		{
+			name:                "no preferred",
+			aaptPreferredConfig: nil,
+			aaptPrebuiltDPI:     []string{},
+			expected:            "prebuilts/apk/app.apk",
+		},
+		{
+			name:                "AAPTPreferredConfig matches",
+			aaptPreferredConfig: proptools.StringPtr("xhdpi"),
+			aaptPrebuiltDPI:     []string{"xxhdpi", "ldpi"},
+			expected:            "prebuilts/apk/app_xhdpi.apk",
+		},
+		{
+			name:                "AAPTPrebuiltDPI matches",
+			aaptPreferredConfig: proptools.StringPtr("mdpi"),
+			aaptPrebuiltDPI:     []string{"xxhdpi", "xhdpi"},
+			expected:            "prebuilts/apk/app_xxhdpi.apk",
+		},
+		{
+			name:                "non-first AAPTPrebuiltDPI matches",
+			aaptPreferredConfig: proptools.StringPtr("mdpi"),
+			aaptPrebuiltDPI:     []string{"ldpi", "xhdpi"},
+			expected:            "prebuilts/apk/app_xhdpi.apk",
+		},
+		{
+			name:                "no matches",
+			aaptPreferredConfig: proptools.StringPtr("mdpi"),
+			aaptPrebuiltDPI:     []string{"ldpi", "xxxhdpi"},
+			expected:            "prebuilts/apk/app.apk",
+		},
+	}
+
+	jniRuleRe := regexp.MustCompile("^if \\(zipinfo (\\S+)")
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig(nil)
+			config.TestProductVariables.AAPTPreferredConfig = test.aaptPreferredConfig
+			config.TestProductVariables.AAPTPrebuiltDPI = test.aaptPrebuiltDPI
+			ctx := testAppContext(config, bp, nil)
+
+			run(t, ctx, config)
+
+			variant := ctx.ModuleForTests("foo", "android_common")
+			jniRuleCommand := variant.Output("jnis-uncompressed/foo.apk").RuleParams.Command
+			matches := jniRuleRe.FindStringSubmatch(jniRuleCommand)
+			if len(matches) != 2 {
+				t.Errorf("failed to extract the src apk path from %q", jniRuleCommand)
+			}
+			if test.expected != matches[1] {
+				t.Errorf("wrong src apk, expected: %q got: %q", test.expected, matches[1])
+			}
+		})
+	}
+}
+
+func TestAndroidAppImport_ArchVariants(t *testing.T) {
+	bp := `
+		android_app_import {
+			name: "foo",
+			apk: "prebuilts/apk/app.apk",
+			arch: {
+				arm64: {
+					apk: "prebuilts/apk/app_arm64.apk",
+				},
+				x86: {
+					apk: "prebuilts/apk/app_x86.apk",
+				},
+			},
+			presigned: true,
+		}
+		`
+
+	// The test config's primary device arch is arm64.
+	ctx := testJava(t, bp)
+
+	variant := ctx.ModuleForTests("foo", "android_common")
+	jniRuleCommand := variant.Output("jnis-uncompressed/foo.apk").RuleParams.Command
+	if !strings.Contains(jniRuleCommand, "prebuilts/apk/app_arm64.apk") {
+		t.Errorf("expected prebuilts/apk/app_arm64.apk to be selected, got %q", jniRuleCommand)
+	}
+}
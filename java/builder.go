@@ -81,6 +81,33 @@ var (
 		},
 		"javacFlags", "bootClasspath", "classpath", "srcJars", "outDir", "javaVersion")
 
+	// turbineApt runs annotation processors once over all of a module's sources and writes the
+	// generated sources into a .srcjar and the generated resources into a .jar, so that they
+	// can be compiled by javac (or each javac shard) with annotation processing disabled.
+	turbineApt = pctx.AndroidStaticRule("turbineApt",
+		blueprint.RuleParams{
+			Command: `rm -rf "$outDir" && mkdir -p "$outDir" && ` +
+				`${config.JavaCmd} -jar ${config.TurbineJar} ` +
+				`--gensrc_output $out.tmp --resource_output $resOut.tmp ` +
+				`--temp_dir "$outDir" --sources @$out.rsp  --source_jars $srcJars ` +
+				`--javacopts ${config.CommonJdkFlags} ` +
+				`$javacFlags -source $javaVersion -target $javaVersion -- $bootClasspath $classpath ` +
+				`--processorpath $processorpath --processors $processor && ` +
+				`${config.Ziptime} $out.tmp && ${config.Ziptime} $resOut.tmp && ` +
+				`(if cmp -s $out.tmp $out ; then rm $out.tmp ; else mv $out.tmp $out ; fi ) && ` +
+				`(if cmp -s $resOut.tmp $resOut ; then rm $resOut.tmp ; else mv $resOut.tmp $resOut ; fi )`,
+			CommandDeps: []string{
+				"${config.TurbineJar}",
+				"${config.JavaCmd}",
+				"${config.Ziptime}",
+			},
+			Rspfile:        "$out.rsp",
+			RspfileContent: "$in",
+			Restat:         true,
+		},
+		"javacFlags", "bootClasspath", "classpath", "processorpath", "processor", "srcJars", "outDir",
+		"javaVersion", "resOut")
+
 	jar = pctx.AndroidStaticRule("jar",
 		blueprint.RuleParams{
 			Command:        `${config.SoongZipCmd} -jar -o $out @$out.rsp`,
@@ -229,6 +256,47 @@ func TransformJavaToHeaderClasses(ctx android.ModuleContext, outputFile android.
 	})
 }
 
+// TurbineApt runs the annotation processors in flags.processorPath over srcFiles and srcJars,
+// writing the generated sources to outputSrcJar and the generated resources to outputResJar.
+func TurbineApt(ctx android.ModuleContext, outputSrcJar, outputResJar android.WritablePath,
+	srcFiles, srcJars android.Paths, flags javaBuilderFlags) {
+
+	var deps android.Paths
+	deps = append(deps, srcJars...)
+	deps = append(deps, flags.bootClasspath...)
+	deps = append(deps, flags.classpath...)
+	deps = append(deps, flags.processorPath...)
+
+	var bootClasspath string
+	if len(flags.bootClasspath) == 0 && ctx.Device() {
+		// explicitly specify -bootclasspath "" if the bootclasspath is empty to
+		// ensure java does not fall back to the default bootclasspath.
+		bootClasspath = `--bootclasspath ""`
+	} else {
+		bootClasspath = strings.Join(flags.bootClasspath.FormTurbineClasspath("--bootclasspath "), " ")
+	}
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:           turbineApt,
+		Description:    "turbine apt",
+		Output:         outputSrcJar,
+		ImplicitOutput: outputResJar,
+		Inputs:         srcFiles,
+		Implicits:      deps,
+		Args: map[string]string{
+			"javacFlags":    flags.javacFlags,
+			"bootClasspath": bootClasspath,
+			"srcJars":       strings.Join(srcJars.Strings(), " "),
+			"classpath":     strings.Join(flags.classpath.FormTurbineClasspath("--classpath "), " "),
+			"processorpath": strings.Join(flags.processorPath.Strings(), " "),
+			"processor":     strings.Replace(flags.processor, ",", " ", -1),
+			"outDir":        android.PathForModuleOut(ctx, "turbine-apt", "classes").String(),
+			"javaVersion":   flags.javaVersion,
+			"resOut":        outputResJar.String(),
+		},
+	})
+}
+
 // transformJavaToClasses takes source files and converts them to a jar containing .class files.
 // srcFiles is a list of paths to sources, srcJars is a list of paths to jar files that contain
 // sources.  flags contains various command line flags to be passed to the compiler.
@@ -152,3 +152,10 @@ func init() {
 	pctx.HostBinToolVariable("Class2Greylist", "class2greylist")
 	pctx.HostBinToolVariable("HiddenAPI", "hiddenapi")
 }
+
+// JavaCmd returns the path to the java binary of the host JDK, for use as a tool in a RuleBuilder
+// command.
+func JavaCmd(ctx android.PathContext) android.SourcePath {
+	// This is set up and guaranteed by soong_ui
+	return android.PathForSource(ctx, ctx.Config().Getenv("ANDROID_JAVA_HOME"), "bin/java")
+}
@@ -28,6 +28,10 @@ type dexpreopter struct {
 	isTest          bool
 	isInstallable   bool
 
+	// isPresignedPrebuilt is set for prebuilt APKs whose signature must be preserved, the dex files are never
+	// stripped from them.
+	isPresignedPrebuilt bool
+
 	builtInstalled string
 }
 
@@ -177,7 +181,7 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Mo
 		NoCreateAppImage:    !BoolDefault(d.dexpreoptProperties.Dex_preopt.App_image, true),
 		ForceCreateAppImage: BoolDefault(d.dexpreoptProperties.Dex_preopt.App_image, false),
 
-		NoStripping:     Bool(d.dexpreoptProperties.Dex_preopt.No_stripping),
+		NoStripping:     Bool(d.dexpreoptProperties.Dex_preopt.No_stripping) || d.isPresignedPrebuilt,
 		StripInputPath:  dexJarFile,
 		StripOutputPath: strippedDexJarFile.OutputPath,
 	}
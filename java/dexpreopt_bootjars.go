@@ -66,7 +66,8 @@ type bootImage struct {
 	vdexInstalls       map[android.ArchType]android.RuleBuilderInstalls
 	unstrippedInstalls map[android.ArchType]android.RuleBuilderInstalls
 
-	profileInstalls android.RuleBuilderInstalls
+	profileInstalls          android.RuleBuilderInstalls
+	preloadedClassesInstalls android.RuleBuilderInstalls
 }
 
 func newBootImage(ctx android.PathContext, config bootImageConfig) *bootImage {
@@ -135,6 +136,7 @@ func (d *dexpreoptBootJars) GenerateBuildActions(ctx android.SingletonContext) {
 
 	// Always create the default boot image first, to get a unique profile rule for all images.
 	d.defaultBootImage = buildBootImage(ctx, defaultBootImageConfig(ctx))
+	preloadedClassesRule(ctx, d.defaultBootImage)
 	if global.GenerateApexImage {
 		d.otherImages = append(d.otherImages, buildBootImage(ctx, apexBootImageConfig(ctx)))
 	}
@@ -247,8 +249,8 @@ func buildBootImageRuleForArch(ctx android.SingletonContext, image *bootImage,
 	if profile != nil {
 		cmd.FlagWithArg("--compiler-filter=", "speed-profile")
 		cmd.FlagWithInput("--profile-file=", profile)
-	} else if global.PreloadedClasses.Valid() {
-		cmd.FlagWithInput("--image-classes=", global.PreloadedClasses.Path())
+	} else if preloadedClasses := bootImagePreloadedClasses(ctx); preloadedClasses.Valid() {
+		cmd.FlagWithInput("--image-classes=", preloadedClasses.Path())
 	}
 
 	if global.DirtyImageObjects.Valid() {
@@ -350,12 +352,12 @@ func bootImageProfileRule(ctx android.SingletonContext, image *bootImage, missin
 		rule.MissingDeps(missingDeps)
 
 		var bootImageProfile android.Path
-		if len(global.BootImageProfiles) > 1 {
+		if profiles := bootImageProfiles(ctx); len(profiles) > 1 {
 			combinedBootImageProfile := image.dir.Join(ctx, "boot-image-profile.txt")
-			rule.Command().Text("cat").Inputs(global.BootImageProfiles).Text(">").Output(combinedBootImageProfile)
+			rule.Command().Text("cat").Inputs(profiles).Text(">").Output(combinedBootImageProfile)
 			bootImageProfile = combinedBootImageProfile
-		} else if len(global.BootImageProfiles) == 1 {
-			bootImageProfile = global.BootImageProfiles[0]
+		} else if len(profiles) == 1 {
+			bootImageProfile = profiles[0]
 		} else {
 			// If not set, use the default.  Some branches like master-art-host don't have frameworks/base, so manually
 			// handle the case that the default is missing.  Those branches won't attempt to build the profile rule,
@@ -392,6 +394,43 @@ func bootImageProfileRule(ctx android.SingletonContext, image *bootImage, missin
 
 var bootImageProfileRuleKey = android.NewOnceKey("bootImageProfileRule")
 
+// bootImageProfiles returns the checked-in profiles that are combined into the boot image profile.  The profiles
+// selected by the product with DexpreoptBootImageProfiles take precedence over the ones in the global dexpreopt config.
+func bootImageProfiles(ctx android.PathContext) android.Paths {
+	if profiles := ctx.Config().DexpreoptBootImageProfiles(); len(profiles) > 0 {
+		return android.PathsForSource(ctx, profiles)
+	}
+	return dexpreoptGlobalConfig(ctx).BootImageProfiles
+}
+
+// bootImagePreloadedClasses returns the preloaded-classes file, which lists the classes to put in the boot image when
+// it is not compiled with a profile and is read by the zygote at runtime.  The file selected by the product with
+// DexpreoptPreloadedClasses takes precedence over the one in the global dexpreopt config.
+func bootImagePreloadedClasses(ctx android.PathContext) android.OptionalPath {
+	if preloadedClasses := ctx.Config().DexpreoptPreloadedClasses(); preloadedClasses != "" {
+		return android.OptionalPathForPath(android.PathForSource(ctx, preloadedClasses))
+	}
+	return dexpreoptGlobalConfig(ctx).PreloadedClasses
+}
+
+// preloadedClassesRule copies the preloaded-classes file next to the boot image so that it is installed to
+// /system/etc/preloaded-classes together with it.
+func preloadedClassesRule(ctx android.SingletonContext, image *bootImage) {
+	preloadedClasses := bootImagePreloadedClasses(ctx)
+	if !preloadedClasses.Valid() {
+		return
+	}
+
+	output := image.dir.Join(ctx, "preloaded-classes")
+
+	rule := android.NewRuleBuilder()
+	rule.Command().Text("cp -f").Input(preloadedClasses.Path()).Output(output)
+	rule.Install(output, "/system/etc/preloaded-classes")
+	rule.Build(pctx, ctx, "preloadedClasses", "preloaded-classes")
+
+	image.preloadedClassesInstalls = rule.Installs()
+}
+
 func dumpOatRules(ctx android.SingletonContext, image *bootImage) {
 	var archs []android.ArchType
 	for arch := range image.images {
@@ -441,6 +480,7 @@ func (d *dexpreoptBootJars) MakeVars(ctx android.MakeVarsContext) {
 	image := d.defaultBootImage
 	if image != nil {
 		ctx.Strict("DEXPREOPT_IMAGE_PROFILE_BUILT_INSTALLED", image.profileInstalls.String())
+		ctx.Strict("DEXPREOPT_IMAGE_PRELOADED_CLASSES_BUILT_INSTALLED", image.preloadedClassesInstalls.String())
 		ctx.Strict("DEXPREOPT_BOOTCLASSPATH_DEX_FILES", strings.Join(image.dexPaths.Strings(), " "))
 		ctx.Strict("DEXPREOPT_BOOTCLASSPATH_DEX_LOCATIONS", strings.Join(image.dexLocations, " "))
 
@@ -22,6 +22,8 @@ import (
 
 	"android/soong/android"
 	"android/soong/dexpreopt"
+
+	"github.com/google/blueprint/proptools"
 )
 
 func TestDexpreoptBootJars(t *testing.T) {
@@ -111,3 +113,57 @@ func TestDexpreoptBootJars(t *testing.T) {
 		t.Errorf("want outputs %q\n got outputs %q", expectedOutputs, outputs)
 	}
 }
+
+func TestDexpreoptBootImageProfile(t *testing.T) {
+	bp := `
+		java_library {
+			name: "bar",
+			srcs: ["b.java"],
+			installable: true,
+		}
+	`
+
+	config := testConfig(nil)
+	config.TestProductVariables.DexpreoptBootImageProfiles = []string{"profiles/a.txt", "profiles/b.txt"}
+	config.TestProductVariables.DexpreoptPreloadedClasses = proptools.StringPtr("profiles/preloaded-classes")
+
+	pathCtx := android.PathContextForTesting(config, nil)
+	dexpreoptConfig := dexpreopt.GlobalConfigForTests(pathCtx)
+	dexpreoptConfig.RuntimeApexJars = []string{"bar"}
+	dexpreoptConfig.UseProfileForBootImage = true
+	dexpreoptConfig.BootImageProfiles = android.PathsForTesting("global-profile.txt")
+	setDexpreoptTestGlobalConfig(config, dexpreoptConfig)
+
+	ctx := testContext(config, bp, map[string][]byte{
+		"profiles/a.txt":             nil,
+		"profiles/b.txt":             nil,
+		"profiles/preloaded-classes": nil,
+	})
+
+	ctx.RegisterSingletonType("dex_bootjars", android.SingletonFactoryAdaptor(dexpreoptBootJarsFactory))
+
+	run(t, ctx, config)
+
+	dexpreoptBootJars := ctx.SingletonForTests("dex_bootjars")
+
+	// The product's profiles replace the ones from the global config and are combined into one.
+	combined := dexpreoptBootJars.Output("boot-image-profile.txt")
+	if g, w := combined.Implicits.Strings(), []string{"profiles/a.txt", "profiles/b.txt"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("want profile inputs %q\n got %q", w, g)
+	}
+
+	profile := dexpreoptBootJars.Output("boot.prof")
+	if !android.InList(combined.Output.String(), profile.Implicits.Strings()) {
+		t.Errorf("want %q in boot.prof inputs %q", combined.Output.String(), profile.Implicits.Strings())
+	}
+
+	bootArt := dexpreoptBootJars.Output("boot.art")
+	if !android.InList(profile.Output.String(), bootArt.Implicits.Strings()) {
+		t.Errorf("want %q in boot.art inputs %q", profile.Output.String(), bootArt.Implicits.Strings())
+	}
+
+	preloadedClasses := dexpreoptBootJars.Output("preloaded-classes")
+	if g, w := preloadedClasses.Implicits.Strings(), []string{"profiles/preloaded-classes"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("want preloaded-classes inputs %q\n got %q", w, g)
+	}
+}
@@ -19,14 +19,20 @@ package java
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 
 	"android/soong/android"
+	"android/soong/java/config"
 )
 
+func init() {
+	android.RegisterSingletonType("jacoco", jacocoSingletonFactory)
+}
+
 var (
 	jacoco = pctx.AndroidStaticRule("jacoco", blueprint.RuleParams{
 		Command: `rm -rf $tmpDir && mkdir -p $tmpDir && ` +
@@ -80,6 +86,12 @@ func (j *Module) jacocoModuleToZipCommand(ctx android.ModuleContext) string {
 	if err != nil {
 		ctx.PropertyErrorf("jacoco.exclude_filter", "%s", err.Error())
 	}
+	// Like Make, never instrument the test frameworks or jacoco itself.
+	defaultExcludes, err := jacocoFiltersToSpecs(config.DefaultJacocoExcludeFilter)
+	if err != nil {
+		ctx.ModuleErrorf("invalid default jacoco exclude filter: %s", err.Error())
+	}
+	excludes = append(excludes, defaultExcludes...)
 
 	return jacocoFiltersToZipCommand(includes, excludes)
 }
@@ -139,3 +151,84 @@ func jacocoFilterToSpec(filter string) (string, error) {
 
 	return spec, nil
 }
+
+type jacocoReportClassesProvider interface {
+	android.Module
+	JacocoReportClassesFile() android.Path
+}
+
+// JacocoReportClassesFile returns the jar containing the uninstrumented versions of the classes
+// that were instrumented by jacoco, or nil if the module was not instrumented.
+func (j *Module) JacocoReportClassesFile() android.Path {
+	return j.jacocoReportClassesFile
+}
+
+// jacocoReportClassesAllJar returns the path to the coverage metadata jar that collects the
+// uninstrumented classes of every module instrumented by Soong.  It is needed alongside the
+// coverage data collected on the device to generate a report.
+func jacocoReportClassesAllJar(ctx android.PathContext) android.OutputPath {
+	return android.PathForOutput(ctx, "jacoco", "jacoco-report-classes-all.jar")
+}
+
+func jacocoSingletonFactory() android.Singleton {
+	return &jacocoSingleton{}
+}
+
+type jacocoSingleton struct {
+	instrumentedModules []string
+	reportClassesAllJar android.Path
+}
+
+func (j *jacocoSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	if !ctx.Config().IsEnvTrue("EMMA_INSTRUMENT") {
+		return
+	}
+
+	type reportClassesJar struct {
+		name string
+		jar  android.Path
+	}
+	var jars []reportClassesJar
+
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(jacocoReportClassesProvider); ok && m.Enabled() {
+			if jar := m.JacocoReportClassesFile(); jar != nil {
+				jars = append(jars, reportClassesJar{ctx.ModuleName(m), jar})
+			}
+		}
+	})
+
+	if len(jars) == 0 {
+		return
+	}
+
+	sort.SliceStable(jars, func(i, k int) bool { return jars[i].name < jars[k].name })
+
+	outputFile := jacocoReportClassesAllJar(ctx)
+
+	// Place each module's jar in a directory named after the module, the jars of different
+	// modules usually share the same base name.
+	rule := android.NewRuleBuilder()
+	cmd := rule.Command().
+		Tool(ctx.Config().HostToolPath(ctx, "soong_zip")).
+		FlagWithOutput("-o ", outputFile)
+	var names []string
+	for _, jar := range jars {
+		cmd.FlagWithArg("-P ", jar.name).
+			FlagWithArg("-C ", filepath.Dir(jar.jar.String())).
+			FlagWithInput("-f ", jar.jar)
+		names = append(names, jar.name)
+	}
+	rule.Build(pctx, ctx, "jacoco_report_classes_all", "jacoco report classes")
+
+	j.instrumentedModules = android.FirstUniqueStrings(names)
+	j.reportClassesAllJar = outputFile
+}
+
+func (j *jacocoSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if j.reportClassesAllJar == nil {
+		return
+	}
+	ctx.Strict("SOONG_JACOCO_REPORT_CLASSES_ALL_JAR", j.reportClassesAllJar.String())
+	ctx.Strict("SOONG_JACOCO_INSTRUMENTED_MODULES", strings.Join(j.instrumentedModules, " "))
+}
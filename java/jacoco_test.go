@@ -14,7 +14,13 @@
 
 package java
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+	"android/soong/java/config"
+)
 
 func TestJacocoFilterToSpecs(t *testing.T) {
 	testCases := []struct {
@@ -103,3 +109,93 @@ func TestJacocoFiltersToZipCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestJacocoInstrumentation(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["b.java"],
+		}
+	`
+
+	testCases := []struct {
+		name         string
+		paths        []string
+		excludePaths []string
+		instrumented bool
+	}{
+		{
+			name:         "default",
+			instrumented: true,
+		},
+		{
+			name:         "wildcard",
+			paths:        []string{"*"},
+			instrumented: true,
+		},
+		{
+			name:         "other path",
+			paths:        []string{"other"},
+			instrumented: false,
+		},
+		{
+			name:         "excluded path",
+			excludePaths: []string{"."},
+			instrumented: false,
+		},
+	}
+
+	defaultExcludes, err := jacocoFiltersToSpecs(config.DefaultJacocoExcludeFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			config := testConfig(map[string]string{"EMMA_INSTRUMENT": "true"})
+			config.TestProductVariables.JavaCoveragePaths = test.paths
+			config.TestProductVariables.JavaCoverageExcludePaths = test.excludePaths
+			ctx := testContext(config, bp, nil)
+			ctx.RegisterSingletonType("jacoco", android.SingletonFactoryAdaptor(jacocoSingletonFactory))
+			run(t, ctx, config)
+
+			// Libraries are only instrumented when listed in InstrumentFrameworkModules.
+			if bar := ctx.ModuleForTests("bar", "android_common").MaybeRule("jacoco"); bar.Rule != nil {
+				t.Errorf("expected bar not to be instrumented")
+			}
+
+			foo := ctx.ModuleForTests("foo", "android_common").MaybeRule("jacoco")
+			if g, w := foo.Rule != nil, test.instrumented; g != w {
+				t.Fatalf("expected foo instrumented %v, got %v", w, g)
+			}
+
+			if test.instrumented {
+				for _, exclude := range defaultExcludes {
+					if !strings.Contains(foo.Args["stripSpec"], "-x "+exclude) {
+						t.Errorf("expected default exclude %q in %q", exclude, foo.Args["stripSpec"])
+					}
+				}
+			}
+
+			allJar := ctx.SingletonForTests("jacoco").MaybeOutput("jacoco/jacoco-report-classes-all.jar")
+			if !test.instrumented {
+				if allJar.Rule != nil {
+					t.Errorf("expected no jacoco-report-classes-all.jar")
+				}
+				return
+			}
+
+			reportJar := foo.ImplicitOutput.String()
+			if !android.InList(reportJar, allJar.Implicits.Strings()) {
+				t.Errorf("expected %q in inputs of %q, got %q", reportJar, allJar.Output.String(),
+					allJar.Implicits.Strings())
+			}
+		})
+	}
+}
@@ -162,6 +162,7 @@ type CompilerProperties struct {
 		// Supports '*' as the last character of an entry in the list as a wildcard match.
 		// If preceded by '.' it matches all classes in the package and subpackages, otherwise
 		// it matches classes in the package that have the class name as a prefix.
+		// The junit, jacoco and mockito classes are always excluded, as with Make.
 		Exclude_filter []string
 	}
 
@@ -348,11 +349,28 @@ func (j *Module) Srcs() android.Paths {
 	return append(android.Paths{j.outputFile}, j.extraOutputFiles...)
 }
 
+func (j *Module) OutputFiles(tag string) (android.Paths, error) {
+	switch tag {
+	case "":
+		return j.Srcs(), nil
+	case ".jar":
+		return android.Paths{j.implementationAndResourcesJar}, nil
+	case ".proguard-map", ".proguard_map":
+		if j.proguardDictionary == nil {
+			return nil, fmt.Errorf("module is not optimized, there is no proguard map")
+		}
+		return android.Paths{j.proguardDictionary}, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+	}
+}
+
 func (j *Module) DexJarFile() android.Path {
 	return j.dexJarFile
 }
 
 var _ android.SourceFileProducer = (*Module)(nil)
+var _ android.OutputFileProducer = (*Module)(nil)
 
 type Dependency interface {
 	HeaderJars() android.Paths
@@ -435,7 +453,9 @@ type jniLib struct {
 }
 
 func (j *Module) shouldInstrument(ctx android.BaseContext) bool {
-	return j.properties.Instrument && ctx.Config().IsEnvTrue("EMMA_INSTRUMENT")
+	return j.properties.Instrument &&
+		ctx.Config().IsEnvTrue("EMMA_INSTRUMENT") &&
+		ctx.DeviceConfig().JavaCoverageEnabledForPath(ctx.ModuleDir())
 }
 
 func (j *Module) shouldInstrumentStatic(ctx android.BaseContext) bool {
@@ -826,10 +846,10 @@ func (j *Module) collectDeps(ctx android.ModuleContext) deps {
 				deps.staticHeaderJars = append(deps.staticHeaderJars, dep.Srcs()...)
 			}
 		default:
-			switch tag {
-			case android.DefaultsDepTag, android.SourceDepTag:
+			switch {
+			case tag == android.DefaultsDepTag, android.IsSourceDepTag(tag):
 				// Nothing to do
-			case systemModulesTag:
+			case tag == systemModulesTag:
 				if deps.systemModules != nil {
 					panic("Found two system module dependencies")
 				}
@@ -1075,20 +1095,30 @@ func (j *Module) compile(ctx android.ModuleContext, extraSrcJars ...android.Path
 
 	jars := append(android.Paths(nil), kotlinJars...)
 
+	enable_sharding := false
+	useTurbine := ctx.Device() && !ctx.Config().IsEnvFalse("TURBINE_ENABLED") && !deps.disableTurbine
+	if useTurbine && j.properties.Javac_shard_size != nil && *(j.properties.Javac_shard_size) > 0 {
+		enable_sharding = true
+		if len(flags.processorPath) > 0 {
+			// Annotation processors only see the sources of the shard they run in, and would
+			// generate each class once per shard.  Run them once over all of the sources with
+			// turbine instead, and compile the generated sources alongside the module's own.
+			aptSrcJar := android.PathForModuleOut(ctx, "turbine-apt", "anno.srcjar")
+			aptResJar := android.PathForModuleOut(ctx, "turbine-apt", "anno.res.jar")
+			TurbineApt(ctx, aptSrcJar, aptResJar, uniqueSrcFiles, srcJars, flags)
+			srcJars = append(srcJars, aptSrcJar)
+			jars = append(jars, aptResJar)
+			// Disable annotation processing in javac, it's already been handled by turbine
+			flags.processorPath = nil
+			flags.processor = ""
+		}
+	}
+
 	// Store the list of .java files that was passed to javac
 	j.compiledJavaSrcs = uniqueSrcFiles
 	j.compiledSrcJars = srcJars
 
-	enable_sharding := false
-	if ctx.Device() && !ctx.Config().IsEnvFalse("TURBINE_ENABLED") && !deps.disableTurbine {
-		if j.properties.Javac_shard_size != nil && *(j.properties.Javac_shard_size) > 0 {
-			enable_sharding = true
-			// Formerly, there was a check here that prevented annotation processors
-			// from being used when sharding was enabled, as some annotation processors
-			// do not function correctly in sharded environments. It was removed to
-			// allow for the use of annotation processors that do function correctly
-			// with sharding enabled. See: b/77284273.
-		}
+	if useTurbine {
 		j.headerJarFile = j.compileJavaHeader(ctx, uniqueSrcFiles, srcJars, deps, flags, jarName, kotlinJars)
 		if ctx.Failed() {
 			return
@@ -1593,6 +1623,12 @@ type testProperties struct {
 	// list of files or filegroup modules that provide data that should be installed alongside
 	// the test
 	Data []string `android:"path"`
+
+	Test_options struct {
+		// If set, the host variant of the test is a unit test that is run during the build by
+		// the host-unit-tests target.
+		Unit_test *bool
+	}
 }
 
 type testHelperLibraryProperties struct {
@@ -1608,6 +1644,8 @@ type Test struct {
 
 	testConfig android.Path
 	data       android.Paths
+
+	unitTestResults android.OptionalPath
 }
 
 type TestHelperLibrary struct {
@@ -1621,8 +1659,64 @@ func (j *Test) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	j.data = android.PathsForModuleSrc(ctx, j.testProperties.Data)
 
 	j.Library.GenerateAndroidBuildActions(ctx)
+
+	if Bool(j.testProperties.Test_options.Unit_test) && ctx.Host() && android.CanRunHostUnitTest(ctx) &&
+		j.implementationAndResourcesJar != nil {
+		j.runUnitTest(ctx)
+	}
+}
+
+// runUnitTest runs the JUnit tests in the test jar with JUnitCore, using the test jar and the
+// implementation jars of its transitive libs as the classpath.
+func (j *Test) runUnitTest(ctx android.ModuleContext) {
+	classpath := android.Paths{j.implementationAndResourcesJar}
+	ctx.WalkDeps(func(child, parent android.Module) bool {
+		tag := ctx.OtherModuleDependencyTag(child)
+		if tag != libTag && tag != staticLibTag {
+			return false
+		}
+		// The jars of static_libs are already merged into the jar of the module that uses them,
+		// but their libs are still needed at runtime.
+		if dep, ok := child.(Dependency); ok && tag == libTag {
+			classpath = append(classpath, dep.ImplementationAndResourcesJars()...)
+		}
+		return true
+	})
+	classpath = android.FirstUniquePaths(classpath)
+
+	rule := android.NewRuleBuilder()
+	cmd, results := android.HostUnitTestCommand(ctx, rule)
+	cmd.FlagWithInput("-junit_jar ", j.implementationAndResourcesJar).
+		Flag("--").
+		Tool(config.JavaCmd(ctx)).
+		FlagWithInputList("-cp ", classpath, ":").
+		Flag("org.junit.runner.JUnitCore").
+		Implicits(j.data)
+	rule.Build(pctx, ctx, "host_unit_test", "run host unit test "+ctx.ModuleName())
+
+	j.unitTestResults = android.OptionalPathForPath(results)
 }
 
+func (j *Test) HostUnitTestResults() android.OptionalPath {
+	return j.unitTestResults
+}
+
+var _ android.HostUnitTestModule = (*Test)(nil)
+
+func (j *Test) TestSuites() []string {
+	return j.testProperties.Test_suites
+}
+
+func (j *Test) TestSuiteInfo() android.TestSuiteInfo {
+	return android.TestSuiteInfo{
+		Output: j.outputFile,
+		Data:   j.data,
+		Config: j.testConfig,
+	}
+}
+
+var _ android.TestSuiteModule = (*Test)(nil)
+
 func (j *TestHelperLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	j.Library.GenerateAndroidBuildActions(ctx)
 }
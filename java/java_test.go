@@ -63,6 +63,7 @@ func testContext(config android.Config, bp string,
 	ctx := android.NewTestArchContext()
 	ctx.RegisterModuleType("android_app", android.ModuleFactoryAdaptor(AndroidAppFactory))
 	ctx.RegisterModuleType("android_app_certificate", android.ModuleFactoryAdaptor(AndroidAppCertificateFactory))
+	ctx.RegisterModuleType("android_app_import", android.ModuleFactoryAdaptor(AndroidAppImportFactory))
 	ctx.RegisterModuleType("android_library", android.ModuleFactoryAdaptor(AndroidLibraryFactory))
 	ctx.RegisterModuleType("android_test", android.ModuleFactoryAdaptor(AndroidTestFactory))
 	ctx.RegisterModuleType("android_test_helper_app", android.ModuleFactoryAdaptor(AndroidTestHelperAppFactory))
@@ -73,6 +74,7 @@ func testContext(config android.Config, bp string,
 	ctx.RegisterModuleType("java_library", android.ModuleFactoryAdaptor(LibraryFactory))
 	ctx.RegisterModuleType("java_library_host", android.ModuleFactoryAdaptor(LibraryHostFactory))
 	ctx.RegisterModuleType("java_test", android.ModuleFactoryAdaptor(TestFactory))
+	ctx.RegisterModuleType("java_test_host", android.ModuleFactoryAdaptor(TestHostFactory))
 	ctx.RegisterModuleType("java_import", android.ModuleFactoryAdaptor(ImportFactory))
 	ctx.RegisterModuleType("java_import_host", android.ModuleFactoryAdaptor(ImportFactoryHost))
 	ctx.RegisterModuleType("java_defaults", android.ModuleFactoryAdaptor(defaultsFactory))
@@ -180,6 +182,8 @@ func testContext(config android.Config, bp string,
 		"jdk8/jre/lib/rt.jar":  nil,
 		"jdk8/lib/tools.jar":   nil,
 
+		"jdk9/bin/java": nil,
+
 		"bar-doc/a.java":                 nil,
 		"bar-doc/b.java":                 nil,
 		"bar-doc/IFoo.aidl":              nil,
@@ -188,6 +192,12 @@ func testContext(config android.Config, bp string,
 
 		"cert/new_cert.x509.pem": nil,
 		"cert/new_cert.pk8":      nil,
+
+		"prebuilts/apk/app.apk":        nil,
+		"prebuilts/apk/app_arm.apk":    nil,
+		"prebuilts/apk/app_arm64.apk":  nil,
+		"prebuilts/apk/app_xhdpi.apk":  nil,
+		"prebuilts/apk/app_xxhdpi.apk": nil,
 	}
 
 	for k, v := range fs {
@@ -221,6 +231,29 @@ func testJava(t *testing.T, bp string) *android.TestContext {
 	return ctx
 }
 
+func testJavaError(t *testing.T, pattern string, bp string) {
+	t.Helper()
+	config := testConfig(nil)
+	ctx := testContext(config, bp, nil)
+
+	pathCtx := android.PathContextForTesting(config, nil)
+	setDexpreoptTestGlobalConfig(config, dexpreopt.GlobalConfigForTests(pathCtx))
+
+	ctx.Register()
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp", "prebuilts/sdk/Android.bp"})
+	if len(errs) > 0 {
+		android.FailIfNoMatchingErrors(t, pattern, errs)
+		return
+	}
+	_, errs = ctx.PrepareBuildActions(config)
+	if len(errs) > 0 {
+		android.FailIfNoMatchingErrors(t, pattern, errs)
+		return
+	}
+
+	t.Fatalf("missing expected error %q (0 errors are returned)", pattern)
+}
+
 func moduleToPath(name string) string {
 	switch {
 	case name == `""`:
@@ -324,6 +357,53 @@ func TestBinary(t *testing.T) {
 
 }
 
+func TestHostUnitTest(t *testing.T) {
+	ctx := testJava(t, `
+		java_library_host {
+			name: "bar",
+			srcs: ["b.java"],
+			libs: ["baz"],
+		}
+
+		java_library_host {
+			name: "baz",
+			srcs: ["c.java"],
+		}
+
+		java_test_host {
+			name: "foo",
+			srcs: ["a.java"],
+			libs: ["bar"],
+			test_options: {
+				unit_test: true,
+			},
+		}
+	`)
+
+	buildOS := android.BuildOs.String()
+
+	foo := ctx.ModuleForTests("foo", buildOS+"_common")
+	fooJar := foo.Module().(Dependency).ImplementationAndResourcesJars()[0].String()
+	barJar := ctx.ModuleForTests("bar", buildOS+"_common").Module().(Dependency).ImplementationAndResourcesJars()[0].String()
+	bazJar := ctx.ModuleForTests("baz", buildOS+"_common").Module().(Dependency).ImplementationAndResourcesJars()[0].String()
+
+	run := foo.Rule("host_unit_test")
+	if g, w := run.Output.String(), filepath.Join(buildDir, "test_results/foo", buildOS+"_common", "foo.xml"); g != w {
+		t.Errorf("expected results %q, got %q", w, g)
+	}
+
+	cmd := run.RuleParams.Command
+	for _, w := range []string{
+		"-junit_jar " + fooJar,
+		"-- jdk9/bin/java -cp " + fooJar + ":" + barJar + ":" + bazJar,
+		"org.junit.runner.JUnitCore",
+	} {
+		if !strings.Contains(cmd, w) {
+			t.Errorf("expected %q in %q", w, cmd)
+		}
+	}
+}
+
 func TestPrebuilts(t *testing.T) {
 	ctx := testJava(t, `
 		java_library {
@@ -579,6 +659,43 @@ func TestGeneratedSources(t *testing.T) {
 	}
 }
 
+func TestProguardMapTag(t *testing.T) {
+	ctx := testJava(t, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			dist: {
+				targets: ["droid"],
+				tag: ".proguard-map",
+			},
+		}
+
+		genrule {
+			name: "gen",
+			srcs: [":foo{.proguard-map}"],
+			out: ["gen.txt"],
+			cmd: "cp $(in) $(out)",
+		}
+	`)
+
+	r8 := ctx.ModuleForTests("foo", "android_common").Rule("r8")
+	genrule := ctx.ModuleForTests("gen", "").Rule("generator")
+	if len(genrule.Inputs) != 1 || genrule.Inputs[0].String() != r8.ImplicitOutput.String() {
+		t.Errorf("gen inputs %v != [%q]", genrule.Inputs, r8.ImplicitOutput.String())
+	}
+
+	testJavaError(t, "module is not optimized, there is no proguard map", `
+		java_library {
+			name: "foo",
+			srcs: ["a.java"],
+			dist: {
+				targets: ["droid"],
+				tag: ".proguard-map",
+			},
+		}
+	`)
+}
+
 func TestTurbine(t *testing.T) {
 	ctx := testJava(t, `
 		java_library {
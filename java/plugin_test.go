@@ -121,3 +121,64 @@ func TestPluginGeneratesApi(t *testing.T) {
 		t.Errorf("foo processor %q != '-processor com.bar'", javac.Args["processor"])
 	}
 }
+
+func TestPluginSharded(t *testing.T) {
+	ctx := testJava(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java", "b.java"],
+			plugins: ["bar"],
+			javac_shard_size: 1,
+		}
+
+		java_plugin {
+			name: "bar",
+			processor_class: "com.bar",
+			srcs: ["c.java"],
+		}
+	`)
+
+	buildOS := android.BuildOs.String()
+
+	foo := ctx.ModuleForTests("foo", "android_common")
+	bar := ctx.ModuleForTests("bar", buildOS+"_common").Rule("javac").Output.String()
+
+	apt := foo.Rule("turbineApt")
+	if !inList(bar, apt.Implicits.Strings()) {
+		t.Errorf("foo turbine apt implicits %v does not contain %q", apt.Implicits.Strings(), bar)
+	}
+	if apt.Args["processorpath"] != bar {
+		t.Errorf("foo turbine apt processorpath %q != %q", apt.Args["processorpath"], bar)
+	}
+	if apt.Args["processor"] != "com.bar" {
+		t.Errorf("foo turbine apt processor %q != 'com.bar'", apt.Args["processor"])
+	}
+
+	aptSrcJar := apt.Output.String()
+
+	for _, shard := range []string{"javac/foo.jar0", "javac/foo.jar1"} {
+		javac := foo.Output(shard)
+		if javac.Args["processorpath"] != "" {
+			t.Errorf("%s: want empty processorpath, got %q", shard, javac.Args["processorpath"])
+		}
+		if javac.Args["processor"] != "-proc:none" {
+			t.Errorf("%s: want '-proc:none' argument, got %q", shard, javac.Args["processor"])
+		}
+	}
+
+	srcJarShard := foo.Output("javac/foo.jar2")
+	if srcJarShard.Args["srcJars"] != aptSrcJar {
+		t.Errorf("foo generated sources shard srcJars %q != %q", srcJarShard.Args["srcJars"], aptSrcJar)
+	}
+
+	turbine := foo.Rule("turbine")
+	if !inList(aptSrcJar, turbine.Implicits.Strings()) {
+		t.Errorf("foo turbine implicits %v does not contain %q", turbine.Implicits.Strings(), aptSrcJar)
+	}
+
+	combined := foo.Output("combined/foo.jar")
+	aptResJar := apt.ImplicitOutput.String()
+	if !inList(aptResJar, combined.Inputs.Strings()) {
+		t.Errorf("foo combined jar inputs %v does not contain %q", combined.Inputs.Strings(), aptResJar)
+	}
+}
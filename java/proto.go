@@ -62,6 +62,9 @@ func protoDeps(ctx android.BottomUpMutatorContext, p *android.ProtoProperties) {
 			} else {
 				ctx.PropertyErrorf("proto.type", "full java protos only supported on the host")
 			}
+		case "stream":
+			// No runtime library for stream protobufs, the generated code only uses the
+			// android.util.proto classes in the framework.
 		default:
 			ctx.PropertyErrorf("proto.type", "unknown proto type %q",
 				String(p.Proto.Type))
@@ -75,6 +78,8 @@ func protoFlags(ctx android.ModuleContext, j *CompilerProperties, p *android.Pro
 	flags.proto = android.GetProtoFlags(ctx, p)
 
 	if String(p.Proto.Plugin) == "" {
+		var plugin string
+
 		switch String(p.Proto.Type) {
 		case "micro":
 			flags.proto.OutTypeFlag = "--javamicro_out"
@@ -85,10 +90,19 @@ func protoFlags(ctx android.ModuleContext, j *CompilerProperties, p *android.Pro
 			flags.proto.OutParams = append(flags.proto.OutParams, "lite")
 		case "full", "":
 			flags.proto.OutTypeFlag = "--java_out"
+		case "stream":
+			flags.proto.OutTypeFlag = "--javastream_out"
+			plugin = "protoc-gen-javastream"
 		default:
 			ctx.PropertyErrorf("proto.type", "unknown proto type %q",
 				String(p.Proto.Type))
 		}
+
+		if plugin != "" {
+			path := ctx.Config().HostToolPath(ctx, plugin)
+			flags.proto.Deps = append(flags.proto.Deps, path)
+			flags.proto.Flags = append(flags.proto.Flags, "--plugin="+path.String())
+		}
 	}
 
 	flags.proto.OutParams = append(flags.proto.OutParams, j.Proto.Output_params...)
@@ -0,0 +1,95 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func testJavaProto(t *testing.T, bp string) *android.TestContext {
+	t.Helper()
+	config := testConfig(nil)
+	ctx := testContext(config, bp, map[string][]byte{
+		"a.proto": nil,
+	})
+	run(t, ctx, config)
+
+	return ctx
+}
+
+func TestProto(t *testing.T) {
+	protoLibs := `
+		java_library {
+			name: "libprotobuf-java-lite",
+			srcs: ["a.java"],
+		}
+
+		java_library {
+			name: "libprotobuf-java-nano",
+			srcs: ["a.java"],
+		}
+	`
+
+	testCases := []struct {
+		name        string
+		protoType   string
+		wantOutFlag string
+		wantPlugin  string
+		wantLib     string
+	}{
+		{"default", "", "--java_out=", "", "libprotobuf-java-lite"},
+		{"lite", "lite", "--java_out=lite:", "", "libprotobuf-java-lite"},
+		{"nano", "nano", "--javanano_out=", "", "libprotobuf-java-nano"},
+		{"stream", "stream", "--javastream_out=", "protoc-gen-javastream", ""},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := testJavaProto(t, protoLibs+`
+				java_library {
+					name: "foo",
+					srcs: ["a.proto"],
+					proto: {
+						type: "`+test.protoType+`",
+					},
+				}
+			`)
+
+			foo := ctx.ModuleForTests("foo", "android_common")
+			cmd := foo.Output("proto/a.srcjar").RuleParams.Command
+
+			if !strings.Contains(cmd, test.wantOutFlag) {
+				t.Errorf("expected %q in %q", test.wantOutFlag, cmd)
+			}
+
+			if test.wantPlugin != "" {
+				if w := "--plugin=" + test.wantPlugin; !strings.Contains(cmd, "--plugin=") ||
+					!strings.Contains(cmd, test.wantPlugin) {
+					t.Errorf("expected %q in %q", w, cmd)
+				}
+			}
+
+			if test.wantLib != "" {
+				classpath := foo.Rule("javac").Args["classpath"]
+				if w := moduleToPath(test.wantLib); !strings.Contains(classpath, w) {
+					t.Errorf("expected %q in classpath %q", w, classpath)
+				}
+			}
+		})
+	}
+}
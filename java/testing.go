@@ -27,6 +27,9 @@ func TestConfig(buildDir string, env map[string]string) android.Config {
 	if env["ANDROID_JAVA8_HOME"] == "" {
 		env["ANDROID_JAVA8_HOME"] = "jdk8"
 	}
+	if env["ANDROID_JAVA_HOME"] == "" {
+		env["ANDROID_JAVA_HOME"] = "jdk9"
+	}
 	config := android.TestArchConfig(buildDir, env)
 
 	return config
@@ -40,6 +40,9 @@ func DefaultsFactory(props ...interface{}) android.Module {
 	module.AddProperties(props...)
 	module.AddProperties(
 		&BaseProperties{},
+		&android.ProtoProperties{},
+		&BinaryProperties{},
+		&TestProperties{},
 	)
 
 	android.InitDefaultsModule(module)
@@ -166,6 +166,9 @@ func Build(ctx Context, config Config, what int) {
 		runMakeProductConfig(ctx, config)
 	}
 
+	// Set up CC_WRAPPER after product config, which exports the CC_WRAPPER set by Make
+	setupCompilerCache(ctx, config)
+
 	if inList("installclean", config.Arguments()) {
 		installClean(ctx, config, what)
 		ctx.Println("Deleted images and staging directories.")
@@ -188,6 +191,10 @@ func Build(ctx Context, config Config, what int) {
 		runKatiBuild(ctx, config)
 		runKatiPackage(ctx, config)
 
+		if config.AuditMakeVars() {
+			auditMakeVars(ctx, config)
+		}
+
 		ioutil.WriteFile(config.LastKatiSuffixFile(), []byte(config.KatiSuffix()), 0777)
 	} else {
 		// Load last Kati Suffix if it exists
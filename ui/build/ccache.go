@@ -0,0 +1,77 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Compiler caches are enabled by setting USE_CCACHE=1 and CCACHE_EXEC to the ccache binary, or
+// USE_SCCACHE=1 and SCCACHE_EXEC to the sccache binary.  The cache is used through CC_WRAPPER,
+// which Soong prepends to C/C++ compile commands (but not to link commands), and CXX_WRAPPER,
+// which Make prepends to C++ compile commands.  An explicitly set CC_WRAPPER or CXX_WRAPPER
+// takes precedence.
+
+// compilerCacheEnv contains the default configuration of ccache, set unless already present in
+// the environment.  Soong uses relative paths and prebuilt compilers, so the cache is keyed on
+// the contents of the compiler instead of its mtime, and the source is preprocessed a second
+// time so that warnings in macro expansions are reported the same way with and without ccache.
+var compilerCacheEnv = map[string]string{
+	"CCACHE_BASEDIR":       "/",
+	"CCACHE_COMPILERCHECK": "content",
+	"CCACHE_CPP2":          "true",
+}
+
+func setupCompilerCache(ctx Context, config Config) {
+	var cacheVar, execVar string
+	if config.UseCcache() {
+		cacheVar, execVar = "USE_CCACHE", "CCACHE_EXEC"
+	} else if config.UseSccache() {
+		cacheVar, execVar = "USE_SCCACHE", "SCCACHE_EXEC"
+	} else {
+		return
+	}
+
+	env := config.Environment()
+	if wrapper, ok := env.Get("CC_WRAPPER"); ok && wrapper != "" {
+		ctx.Verbosef("%s is set, but CC_WRAPPER=%q is used instead", cacheVar, wrapper)
+		return
+	}
+
+	exec, ok := env.Get(execVar)
+	if !ok || exec == "" {
+		ctx.Fatalf("%s is set, but %s is not set to the path of the compiler cache binary", cacheVar, execVar)
+	}
+	if !filepath.IsAbs(exec) {
+		ctx.Fatalf("%s must be an absolute path, got %q", execVar, exec)
+	}
+	if fi, err := os.Stat(exec); err != nil || fi.Mode()&0111 == 0 {
+		ctx.Fatalf("%s=%q is not an executable file", execVar, exec)
+	}
+
+	env.Set("CC_WRAPPER", exec)
+	if wrapper, ok := env.Get("CXX_WRAPPER"); !ok || wrapper == "" {
+		env.Set("CXX_WRAPPER", exec)
+	}
+
+	if cacheVar == "USE_CCACHE" {
+		for k, v := range compilerCacheEnv {
+			if _, ok := env.Get(k); !ok {
+				env.Set(k, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetupCompilerCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soong_ccache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ccache := filepath.Join(dir, "ccache")
+	if err := ioutil.WriteFile(ccache, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		env        []string
+		wrapper    string
+		cxxWrapper string
+		basedir    string
+	}{
+		{
+			name: "disabled",
+			env:  []string{"CCACHE_EXEC=" + ccache},
+		},
+		{
+			name:       "ccache",
+			env:        []string{"USE_CCACHE=1", "CCACHE_EXEC=" + ccache},
+			wrapper:    ccache,
+			cxxWrapper: ccache,
+			basedir:    "/",
+		},
+		{
+			name:       "ccache basedir",
+			env:        []string{"USE_CCACHE=1", "CCACHE_EXEC=" + ccache, "CCACHE_BASEDIR=/src"},
+			wrapper:    ccache,
+			cxxWrapper: ccache,
+			basedir:    "/src",
+		},
+		{
+			name:       "sccache",
+			env:        []string{"USE_SCCACHE=true", "SCCACHE_EXEC=" + ccache},
+			wrapper:    ccache,
+			cxxWrapper: ccache,
+		},
+		{
+			name:       "explicit c++ wrapper",
+			env:        []string{"USE_CCACHE=1", "CCACHE_EXEC=" + ccache, "CXX_WRAPPER=/bin/wrapper"},
+			wrapper:    ccache,
+			cxxWrapper: "/bin/wrapper",
+			basedir:    "/",
+		},
+		{
+			name:    "explicit wrapper",
+			env:     []string{"USE_CCACHE=1", "CCACHE_EXEC=" + ccache, "CC_WRAPPER=/bin/wrapper"},
+			wrapper: "/bin/wrapper",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := Environment(tc.env)
+			config := Config{&configImpl{environ: &env}}
+			setupCompilerCache(testContext(), config)
+
+			if wrapper, _ := env.Get("CC_WRAPPER"); wrapper != tc.wrapper {
+				t.Errorf("expected CC_WRAPPER %q, got %q", tc.wrapper, wrapper)
+			}
+			if wrapper, _ := env.Get("CXX_WRAPPER"); wrapper != tc.cxxWrapper {
+				t.Errorf("expected CXX_WRAPPER %q, got %q", tc.cxxWrapper, wrapper)
+			}
+			if basedir, _ := env.Get("CCACHE_BASEDIR"); basedir != tc.basedir {
+				t.Errorf("expected CCACHE_BASEDIR %q, got %q", tc.basedir, basedir)
+			}
+		})
+	}
+}
@@ -719,6 +719,22 @@ func (c *configImpl) UseGoma() bool {
 	return false
 }
 
+// UseCcache returns whether C/C++ compiles should go through ccache, see setupCompilerCache.
+func (c *configImpl) UseCcache() bool {
+	return c.environ.IsEnvTrue("USE_CCACHE")
+}
+
+// UseSccache returns whether C/C++ compiles should go through sccache, see setupCompilerCache.
+func (c *configImpl) UseSccache() bool {
+	return c.environ.IsEnvTrue("USE_SCCACHE")
+}
+
+// AuditMakeVars returns whether to report the variables exported by Soong that are not referenced
+// by Make, see auditMakeVars.
+func (c *configImpl) AuditMakeVars() bool {
+	return c.environ.IsEnvTrue("SOONG_MAKEVARS_AUDIT")
+}
+
 func (c *configImpl) StartGoma() bool {
 	if !c.UseGoma() {
 		return false
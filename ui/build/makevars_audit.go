@@ -0,0 +1,107 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"android/soong/ui/metrics"
+)
+
+// auditMakeVars writes a report of the variables exported by Soong to Make that are not
+// referenced by any of the makefiles read by the Kati build step, to help prune the interface
+// between Soong and Make.  It runs when SOONG_MAKEVARS_AUDIT is set, which also makes Soong's
+// makevars singleton write the list of exported variables next to the make vars file.  The
+// makefiles read by Kati are listed from the Kati stamp file.  Only literal references are found,
+// so variables that are only referenced through computed names are reported as unused.
+func auditMakeVars(ctx Context, config Config) {
+	ctx.BeginTrace(metrics.RunKati, "audit makevars")
+	defer ctx.EndTrace()
+
+	exportsFile := strings.TrimSuffix(config.SoongMakeVarsMk(), ".mk") + ".exports"
+	exports, err := ioutil.ReadFile(exportsFile)
+	if err != nil {
+		ctx.Println("Failed to read the Soong make vars exports:", err)
+		return
+	}
+
+	stampFile := filepath.Join(config.OutDir(), ".kati_stamp"+config.KatiSuffix()+katiBuildSuffix)
+	cmd := Command(ctx, config, "ckati_stamp_dump",
+		config.PrebuiltBuildTool("ckati_stamp_dump"), "--files", stampFile)
+	files, err := cmd.Output()
+	if err != nil {
+		ctx.Println("Failed to list the makefiles read by Kati:", err)
+		return
+	}
+
+	makefiles := make(map[string][]byte)
+	for _, file := range strings.Fields(string(files)) {
+		// The make vars file defines every exported variable, and the Soong Android.mk file is
+		// generated by Soong too.
+		if file == config.SoongMakeVarsMk() || file == config.SoongAndroidMk() {
+			continue
+		}
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			ctx.Println("Failed to read makefile:", err)
+			return
+		}
+		makefiles[file] = data
+	}
+
+	unused := unusedMakeVars(strings.Fields(string(exports)), makefiles)
+
+	reportFile := strings.TrimSuffix(config.SoongMakeVarsMk(), ".mk") + ".unused"
+	report := &bytes.Buffer{}
+	for _, name := range unused {
+		report.WriteString(name + "\n")
+	}
+	if err := ioutil.WriteFile(reportFile, report.Bytes(), 0666); err != nil {
+		ctx.Println("Failed to write the make vars audit report:", err)
+		return
+	}
+
+	ctx.Printf("%d of %d variables exported by Soong are not referenced by Make, see %s",
+		len(unused), len(strings.Fields(string(exports))), reportFile)
+}
+
+var makeIdentifierRegexp = regexp.MustCompile(`[A-Za-z0-9_]+`)
+
+// unusedMakeVars returns the exported SOONG_<name> variables that are not referenced by any of
+// the makefiles.  A reference to <name> also counts, as the make vars file sets <name> to the
+// value of SOONG_<name> when Make doesn't set it.
+func unusedMakeVars(exports []string, makefiles map[string][]byte) []string {
+	referenced := make(map[string]bool)
+	for _, data := range makefiles {
+		for _, ident := range makeIdentifierRegexp.FindAll(data, -1) {
+			referenced[string(ident)] = true
+		}
+	}
+
+	var unused []string
+	for _, name := range exports {
+		if !referenced[name] && !referenced[strings.TrimPrefix(name, "SOONG_")] {
+			unused = append(unused, name)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnusedMakeVars(t *testing.T) {
+	exports := []string{
+		"SOONG_BAR",
+		"SOONG_BAZ",
+		"SOONG_FOO",
+		"SOONG_QUX",
+	}
+
+	makefiles := map[string][]byte{
+		"build/make/core/main.mk": []byte(`
+ifneq ($(SOONG_FOO),)
+  foo := $(SOONG_FOO)
+endif
+# BAR is set from SOONG_BAR by the make vars file when it is empty
+bar := $(BAR)x
+`),
+		"build/make/core/other.mk": []byte("$(call qux,SOONG_QUXX)\n"),
+	}
+
+	want := []string{"SOONG_BAZ", "SOONG_QUX"}
+	if got := unusedMakeVars(exports, makefiles); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -18,10 +18,10 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 
 	"github.com/google/blueprint/microfactory"
 
+	"android/soong/env"
 	"android/soong/ui/metrics"
 	"android/soong/ui/status"
 )
@@ -52,30 +52,22 @@ func runSoong(ctx Context, config Config) {
 		ctx.BeginTrace(metrics.RunSoong, "environment check")
 		defer ctx.EndTrace()
 
-		envFile := filepath.Join(config.SoongOutDir(), ".soong.environment")
-		envTool := filepath.Join(config.SoongOutDir(), ".bootstrap/bin/soong_env")
-		if _, err := os.Stat(envFile); err == nil {
-			if _, err := os.Stat(envTool); err == nil {
-				cmd := Command(ctx, config, "soong_env", envTool, envFile)
-				cmd.Sandbox = soongSandbox
-
-				var buf strings.Builder
-				cmd.Stdout = &buf
-				cmd.Stderr = &buf
-				if err := cmd.Run(); err != nil {
-					ctx.Verboseln("soong_env failed, forcing manifest regeneration")
-					os.Remove(envFile)
-				}
-
-				if buf.Len() > 0 {
-					ctx.Verboseln(buf.String())
-				}
-			} else {
-				ctx.Verboseln("Missing soong_env tool, forcing manifest regeneration")
-				os.Remove(envFile)
+		envFile := filepath.Join(config.SoongOutDir(), env.UsedEnvFile)
+		getenv := func(key string) string {
+			v, _ := config.Environment().Get(key)
+			return v
+		}
+		if changed, err := env.ChangedEnvVars(envFile, getenv); os.IsNotExist(err) {
+			// soong_build has not run yet, the manifest will be generated anyway
+		} else if err != nil {
+			ctx.Verboseln("Failed to read", envFile, "forcing manifest regeneration:", err)
+			os.Remove(envFile)
+		} else if len(changed) > 0 {
+			ctx.Verboseln("Environment variables used by soong_build changed value, forcing manifest regeneration:")
+			for _, s := range changed {
+				ctx.Verboseln("   ", s)
 			}
-		} else if !os.IsNotExist(err) {
-			ctx.Fatalf("Failed to stat %f: %v", envFile, err)
+			os.Remove(envFile)
 		}
 	}()
 
@@ -129,5 +129,6 @@ func PrebuiltEtcXmlFactory() android.Module {
 	android.InitPrebuiltEtcModule(&module.PrebuiltEtc)
 	// This module is device-only
 	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibCommon)
+	android.InitDefaultableModule(module)
 	return module
 }